@@ -0,0 +1,177 @@
+// Command testclusters lists and cleans up the k3d clusters that were created by testclusters-go.
+//
+// Usage:
+//
+//	testclusters list
+//	testclusters prune [-older-than duration]
+//	testclusters delete <name>
+//	testclusters kubeconfig <name>
+//	testclusters logs [-f] <name>
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/test-clusters/testclusters-go/pkg/cluster"
+)
+
+const usage = `Usage: testclusters <command> [arguments]
+
+Commands:
+  list                               list all clusters created by testclusters-go
+  prune [-older-than duration]       delete clusters whose test process has exited
+  delete <name>                      delete a cluster
+  kubeconfig <name>                  print the kubeconfig of a cluster
+  logs [-f] <name>                   print the k3s server logs of a cluster
+`
+
+// errUsage marks errors that are caused by invalid arguments.
+var errUsage = errors.New("invalid usage")
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	os.Exit(run(ctx, os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	err := dispatch(ctx, args, stdout)
+	if errors.Is(err, errUsage) {
+		_, _ = fmt.Fprintf(stderr, "%s\n%s", err.Error(), usage)
+		return 2
+	}
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "testclusters: %s\n", err.Error())
+		return 1
+	}
+	return 0
+}
+
+func dispatch(ctx context.Context, args []string, stdout io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing command: %w", errUsage)
+	}
+
+	command, args := args[0], args[1:]
+	switch command {
+	case "list":
+		return list(ctx, args, stdout)
+	case "prune":
+		return prune(ctx, args, stdout)
+	case "delete":
+		return deleteCluster(ctx, args)
+	case "kubeconfig":
+		return kubeConfig(ctx, args, stdout)
+	case "logs":
+		return logs(ctx, args, stdout)
+	default:
+		return fmt.Errorf("unknown command %q: %w", command, errUsage)
+	}
+}
+
+func list(ctx context.Context, args []string, stdout io.Writer) error {
+	if _, err := parseFlags("list", args, 0, nil); err != nil {
+		return err
+	}
+
+	clusters, err := cluster.ListClusters(ctx)
+	if err != nil {
+		return err
+	}
+	return printClusters(stdout, clusters, time.Now())
+}
+
+func printClusters(stdout io.Writer, clusters []cluster.ClusterInfo, now time.Time) error {
+	w := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "NAME\tAGE\tOWNER\tORPHANED\tTEST")
+	for _, info := range clusters {
+		age := now.Sub(info.Created).Round(time.Second)
+		owner := fmt.Sprintf("%s:%s", info.OwnerHost, strconv.Itoa(info.OwnerPID))
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%t\t%s\n", info.Name, age, owner, info.IsOrphaned(), info.TestName)
+	}
+	return w.Flush()
+}
+
+func prune(ctx context.Context, args []string, stdout io.Writer) error {
+	var olderThan time.Duration
+	_, err := parseFlags("prune", args, 0, func(flags *flag.FlagSet) {
+		flags.DurationVar(&olderThan, "older-than", 0, "only prune clusters that are older than this duration")
+	})
+	if err != nil {
+		return err
+	}
+
+	deleted, err := cluster.PruneOrphans(ctx, olderThan)
+	for _, name := range deleted {
+		_, _ = fmt.Fprintf(stdout, "deleted %s\n", name)
+	}
+	return err
+}
+
+func deleteCluster(ctx context.Context, args []string) error {
+	names, err := parseFlags("delete", args, 1, nil)
+	if err != nil {
+		return err
+	}
+	return cluster.DeleteCluster(ctx, names[0])
+}
+
+func kubeConfig(ctx context.Context, args []string, stdout io.Writer) error {
+	names, err := parseFlags("kubeconfig", args, 1, nil)
+	if err != nil {
+		return err
+	}
+
+	config, err := cluster.KubeConfig(ctx, names[0])
+	if err != nil {
+		return err
+	}
+	raw, err := clientcmd.Write(*config)
+	if err != nil {
+		return fmt.Errorf("could not serialize kubeconfig: %w", err)
+	}
+	_, err = stdout.Write(raw)
+	return err
+}
+
+func logs(ctx context.Context, args []string, stdout io.Writer) error {
+	var follow bool
+	names, err := parseFlags("logs", args, 1, func(flags *flag.FlagSet) {
+		flags.BoolVar(&follow, "f", false, "follow the logs until interrupted")
+	})
+	if err != nil {
+		return err
+	}
+	return cluster.WriteServerLogs(ctx, names[0], stdout, follow)
+}
+
+// parseFlags parses the flags of a command and checks that exactly the given number of positional arguments remains.
+func parseFlags(command string, args []string, positional int, define func(flags *flag.FlagSet)) ([]string, error) {
+	flags := flag.NewFlagSet(command, flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	if define != nil {
+		define(flags)
+	}
+
+	err := flags.Parse(args)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s: %w", command, err.Error(), errUsage)
+	}
+	if flags.NArg() != positional {
+		return nil, fmt.Errorf("%s expects %d argument(s) but got %d: %w", command, positional, flags.NArg(), errUsage)
+	}
+	return flags.Args(), nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/test-clusters/testclusters-go/pkg/cluster"
+)
+
+func Test_run(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{"missing command", nil, "missing command"},
+		{"unknown command", []string{"explode"}, `unknown command "explode"`},
+		{"missing cluster name", []string{"kubeconfig"}, "kubeconfig expects 1 argument(s) but got 0"},
+		{"too many arguments", []string{"list", "hello-world"}, "list expects 0 argument(s) but got 1"},
+		{"unknown flag", []string{"prune", "-all"}, "prune: flag provided but not defined: -all"},
+		{"invalid duration", []string{"prune", "-older-than", "soon"}, `prune: invalid value "soon" for flag -older-than`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+
+			actual := run(context.Background(), tt.args, stdout, stderr)
+
+			assert.Equal(t, 2, actual)
+			assert.Empty(t, stdout.String())
+			assert.Contains(t, stderr.String(), tt.wantErr)
+			assert.Contains(t, stderr.String(), "Usage: testclusters <command> [arguments]")
+		})
+	}
+}
+
+func Test_printClusters(t *testing.T) {
+	// given
+	now := time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+	clusters := []cluster.ClusterInfo{{
+		Name:      "hello-world-1234abcd",
+		OwnerPID:  4711,
+		OwnerHost: "elsewhere",
+		Created:   now.Add(-90 * time.Second),
+		TestName:  "TestSomething/sub",
+	}}
+	stdout := &bytes.Buffer{}
+
+	// when
+	err := printClusters(stdout, clusters, now)
+
+	// then
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.Equal(t, []string{"NAME", "AGE", "OWNER", "ORPHANED", "TEST"}, strings.Fields(lines[0]))
+	assert.Equal(t, []string{"hello-world-1234abcd", "1m30s", "elsewhere:4711", "false", "TestSomething/sub"}, strings.Fields(lines[1]))
+}
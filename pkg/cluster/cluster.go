@@ -2,8 +2,14 @@ package cluster
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
 	"strconv"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -11,19 +17,16 @@ import (
 	"github.com/k3d-io/k3d/v5/pkg/config"
 	configTypes "github.com/k3d-io/k3d/v5/pkg/config/types"
 	"github.com/k3d-io/k3d/v5/pkg/config/v1alpha5"
-	l "github.com/k3d-io/k3d/v5/pkg/logger"
 	"github.com/k3d-io/k3d/v5/pkg/runtimes"
 	k3dTypes "github.com/k3d-io/k3d/v5/pkg/types"
 	"github.com/phayes/freeport"
 	v1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/tools/clientcmd/api"
-	"k8s.io/client-go/util/retry"
 
 	"github.com/test-clusters/testclusters-go/pkg/naming"
 )
@@ -31,17 +34,19 @@ import (
 const appName = "k8s-containers"
 const DefaultNamespace = "default"
 
-// k3s versions
-// warning: k3s versions are tagged with a `+` separator before `k3s1`, but k3s images use `-`.
-const (
-	K3sVersion1_26 = "v1.26.2-k3s1"
-	K3sVersion1_28 = "v1.28.2-k3s1"
-)
+// startErrorTeardownTimeout limits the time that is spent on terminating a cluster whose start-up failed.
+const startErrorTeardownTimeout = 1 * time.Minute
 
 type Cluster interface {
 	Terminate(ctx context.Context) error
 }
 
+// testCleanup is the part of testing.TB that resources of a test cluster use to clean up after the test.
+type testCleanup interface {
+	Cleanup(f func())
+	Failed() bool
+}
+
 type K3dCluster struct {
 	containerRuntime    runtimes.Runtime
 	clusterConfig       *v1alpha5.ClusterConfig
@@ -49,29 +54,42 @@ type K3dCluster struct {
 	ClusterName         string
 	AdminServiceAccount string
 	clientConfig        *rest.Config
+	options             *clusterOptions
+	logger              *slog.Logger
+	// test is the test that owns the cluster, or nil if the cluster was not created by NewK3dCluster.
+	test testCleanup
+	// releaseSlot gives the cluster's slot back to the limiter of concurrently existing clusters.
+	releaseSlot func()
+	terminateMu sync.Mutex
+	// terminateDone is set once the deletion of the cluster finished with a result that is not caused by its context.
+	terminateDone bool
+	terminateErr  error
+	// terminated is set as soon as the cluster is being terminated.
+	terminated atomic.Bool
 }
 
 // NewK3dCluster creates a completely new cluster within the provided container engine. This method is the usual entry point of a test with testclusters-go.
-func NewK3dCluster(t *testing.T) *K3dCluster {
-	cluster := setupCluster(t)
+func NewK3dCluster(t *testing.T, opts ...ClusterOption) *K3dCluster {
+	cluster := setupCluster(t, opts...)
+	cluster.test = t
 	registerTearDown(t, cluster)
+	watchTestDeadline(t)
 
 	return cluster
 }
 
-func setupCluster(t *testing.T) *K3dCluster {
-	l.Log().Info("testcluster-go: Creating cluster during  ")
+func setupCluster(t *testing.T, opts ...ClusterOption) *K3dCluster {
 	var err error
 	ctx := context.Background()
-	cluster, err := CreateK3dCluster(ctx, "hello-world")
-	if err != nil {
-		t.Errorf("Unexpected error during test setup: %s\n", err)
+	if deadline, ok := t.Deadline(); ok {
+		// fail instead of waiting until `go test -timeout` panics, f. e. for cluster slots that are never released
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, gracefulDeadline(deadline))
+		defer cancel()
 	}
-	l.Log().Info("testcluster-go: Cluster was successfully created")
-
-	err = cluster.waitForDefaultSACreation(ctx)
+	cluster, err := CreateK3dCluster(ctx, "hello-world", append([]ClusterOption{withTestLog(t), withTestName(t.Name())}, opts...)...)
 	if err != nil {
-		t.Errorf("failed to wait for default service account: %s", err.Error())
+		t.Fatalf("Unexpected error during test setup: %s\n", err)
 	}
 
 	return cluster
@@ -79,22 +97,66 @@ func setupCluster(t *testing.T) *K3dCluster {
 
 func registerTearDown(t *testing.T, cluster *K3dCluster) {
 	t.Cleanup(func() {
-		l.Log().Debug("testcluster-go: Terminating cluster during test tear down")
+		dumpDiagnosticsOnFailure(t, cluster)
+
+		if t.Failed() && cluster.options.keepOnFailure && cluster.kubeConfig != nil {
+			err := keepForPostMortem(t, cluster)
+			if err != nil {
+				t.Errorf("Unexpected error while keeping cluster %s: %s\n", cluster.ClusterName, err.Error())
+			}
+			unregisterLiveCluster(cluster)
+			cluster.releaseSlot()
+			return
+		}
+
+		cluster.logger.Debug("testcluster-go: Terminating cluster during test tear down")
 		err := cluster.Terminate(context.Background())
 		if err != nil {
-			l.Log().Info("testcluster-go: Cluster was termination failed")
+			cluster.logger.Info("testcluster-go: Cluster termination failed")
 			t.Errorf("Unexpected error during test tear down: %s\n", err.Error())
+			return
 		}
-		l.Log().Info("testcluster-go: Cluster was successfully terminated")
+		cluster.logger.Info("testcluster-go: Cluster was successfully terminated")
 	})
 }
 
-func createClusterConfig(ctx context.Context, clusterName string) (*v1alpha5.ClusterConfig, error) {
+func createClusterConfig(ctx context.Context, clusterName, k3sVersion string, options *clusterOptions, logger *slog.Logger) (*v1alpha5.ClusterConfig, error) {
+	containerRuntime := options.containerRuntime
 	freeHostPort, err := freeport.GetFreePort()
 	if err != nil {
 		return nil, fmt.Errorf("could not find free port for port-forward: %w", err)
 	}
 
+	simpleConfig := newSimpleConfig(clusterName, k3sVersion, freeHostPort, options)
+	if err := config.ProcessSimpleConfig(&simpleConfig); err != nil {
+		return nil, fmt.Errorf("processing simple cluster config failed: %w", err)
+	}
+
+	clusterConfig, err := config.TransformSimpleToClusterConfig(ctx, containerRuntime, simpleConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to transform cluster config: %w", err)
+	}
+
+	logger.Debug(fmt.Sprintf("===== used cluster config =====\n%#v\n===== =====", clusterConfig))
+
+	clusterConfig, err = config.ProcessClusterConfig(*clusterConfig)
+	if err != nil {
+		if err != nil {
+			return nil, fmt.Errorf("processing cluster config failed: %w", err)
+		}
+	}
+
+	if err = config.ValidateClusterConfig(ctx, containerRuntime, *clusterConfig); err != nil {
+		if err != nil {
+			return nil, fmt.Errorf("failed cluster config validation: %w", err)
+		}
+	}
+
+	return clusterConfig, nil
+}
+
+// newSimpleConfig describes the cluster in k3d's simple config format.
+func newSimpleConfig(clusterName, k3sVersion string, apiHostPort int, options *clusterOptions) v1alpha5.SimpleConfig {
 	k3sRegistryYaml := `
 my.company.registry":
   endpoint:
@@ -108,9 +170,9 @@ my.company.registry":
 		ObjectMeta: configTypes.ObjectMeta{
 			Name: clusterName,
 		},
-		Image:   fmt.Sprintf("%s:%s", k3dTypes.DefaultK3sImageRepo, K3sVersion1_28),
+		Image:   fmt.Sprintf("%s:%s", k3dTypes.DefaultK3sImageRepo, k3sVersion),
 		Servers: 1,
-		Agents:  0,
+		Agents:  options.agents,
 		Options: v1alpha5.SimpleConfigOptions{
 			K3dOptions: v1alpha5.SimpleConfigOptionsK3d{
 				Wait:    true,
@@ -122,7 +184,8 @@ my.company.registry":
 			Create: &v1alpha5.SimpleConfigRegistryCreateConfig{
 				//Name:	fmt.Sprintf("%s-%s-registry", k3dTypes.DefaultObjectNamePrefix, newCluster.Name),
 				// Host:    "0.0.0.0",
-				HostPort: k3dTypes.DefaultRegistryPort, // alternatively the string "random"
+				// a fixed port would collide with the registries of clusters that run in parallel
+				HostPort: "random",
 				// Image:    fmt.Sprintf("%s:%s", k3dTypes.DefaultRegistryImageRepo, k3dTypes.DefaultRegistryImageTag),
 				Proxy: k3dTypes.RegistryProxy{
 					RemoteURL: "https://registry-1.docker.io",
@@ -133,55 +196,57 @@ my.company.registry":
 			Config: k3sRegistryYaml,
 		},
 		ExposeAPI: v1alpha5.SimpleExposureOpts{
-			HostPort: strconv.Itoa(freeHostPort),
+			HostPort: strconv.Itoa(apiHostPort),
 		},
 	}
+	simpleConfig.Options.K3sOptions.ExtraArgs = append(options.disabledComponentArgs(), options.extraArgs...)
 
-	if err := config.ProcessSimpleConfig(&simpleConfig); err != nil {
-		return nil, fmt.Errorf("processing simple cluster config failed: %w", err)
-	}
+	return simpleConfig
+}
 
-	clusterConfig, err := config.TransformSimpleToClusterConfig(ctx, runtimes.SelectedRuntime, simpleConfig)
-	if err != nil {
-		return nil, fmt.Errorf("failed to transform cluster config: %w", err)
-	}
+// TODO allow the user to overwrite our ClusterConfig with her own
+// func CreateK3dClusterWithConfig() ...
 
-	l.Log().Debugf("===== used cluster config =====\n%#v\n===== =====", clusterConfig)
+// CreateK3dCluster creates a completely new K8s cluster with an optional clusterNamePrefix. The prefix is truncated to
+// fit into the length limit of k3d cluster names together with the appended hash.
+func CreateK3dCluster(ctx context.Context, clusterNamePrefix string, opts ...ClusterOption) (*K3dCluster, error) {
+	options := newClusterOptions(opts...)
+	containerRuntime := options.containerRuntime
 
-	clusterConfig, err = config.ProcessClusterConfig(*clusterConfig)
+	k3sVersion, err := K3sVersionFor(options.kubernetesVersion)
 	if err != nil {
-		if err != nil {
-			return nil, fmt.Errorf("processing cluster config failed: %w", err)
-		}
+		return nil, err
 	}
 
-	if err = config.ValidateClusterConfig(ctx, runtimes.SelectedRuntime, *clusterConfig); err != nil {
-		if err != nil {
-			return nil, fmt.Errorf("failed cluster config validation: %w", err)
-		}
+	clusterName, err := naming.GenerateK8sNameWithMaxLength(clusterNamePrefix, k3dTypes.DefaultClusterNameMaxLength)
+	if err != nil {
+		return nil, fmt.Errorf("could not generate cluster name: %w", err)
 	}
-
-	return clusterConfig, nil
-}
-
-// TODO allow the user to overwrite our ClusterConfig with her own
-// func CreateK3dClusterWithConfig() ...
-
-// CreateK3dCluster creates a completely new K8s cluster with an optional clusterNamePrefix.
-func CreateK3dCluster(ctx context.Context, clusterNamePrefix string) (*K3dCluster, error) {
-	containerRuntime := runtimes.SelectedRuntime
-
-	clusterName := naming.MustGenerateK8sName(clusterNamePrefix)
 	cluster := &K3dCluster{
 		containerRuntime: containerRuntime,
 		ClusterName:      clusterName,
+		options:          options,
+		logger:           options.logger.With("cluster", clusterName),
 	}
+	defer captureK3dLogs(clusterName, cluster.logger)()
+	handleInterruptsFromEnv()
+	pruneOrphansOnStartup(ctx, containerRuntime, cluster.logger)
 
-	var err error
-	cluster.clusterConfig, err = createClusterConfig(ctx, clusterName)
+	cluster.releaseSlot, err = clusterSlots.acquire(ctx, options.testName)
 	if err != nil {
 		return nil, err
 	}
+	cluster.logger.Info("testcluster-go: Creating cluster")
+
+	cluster.clusterConfig, err = createClusterConfig(ctx, clusterName, k3sVersion, options, cluster.logger)
+	if err != nil {
+		cluster.releaseSlot()
+		return nil, err
+	}
+	for key, value := range ownerLabels(options.testName) {
+		cluster.clusterConfig.ClusterCreateOpts.GlobalLabels[key] = value
+	}
+	registerLiveCluster(cluster)
 
 	err = client.ClusterRun(ctx, containerRuntime, cluster.clusterConfig)
 	if err != nil {
@@ -192,7 +257,12 @@ func CreateK3dCluster(ctx context.Context, clusterNamePrefix string) (*K3dCluste
 	if err != nil {
 		return cluster, handleStartError(ctx, cluster, err)
 	}
-	l.Log().Debugf("testcluster-go: ===== retrieved kube config ====\n%#v\n===== =====", cluster.kubeConfig)
+	cluster.logger.Debug(fmt.Sprintf("testcluster-go: ===== retrieved kube config ====\n%#v\n===== =====", cluster.kubeConfig))
+
+	cluster.clientConfig, err = clientcmd.NewDefaultClientConfig(*cluster.kubeConfig, nil).ClientConfig()
+	if err != nil {
+		return cluster, handleStartError(ctx, cluster, fmt.Errorf("failed to create client config: %w", err))
+	}
 
 	sa, err := createDefaultRBACForSA(ctx, cluster)
 	if err != nil {
@@ -200,6 +270,22 @@ func CreateK3dCluster(ctx context.Context, clusterNamePrefix string) (*K3dCluste
 	}
 	cluster.AdminServiceAccount = sa
 
+	clientSet, err := cluster.ClientSet()
+	if err != nil {
+		return cluster, handleStartError(ctx, cluster, err)
+	}
+	err = cluster.applyStartupManifests(ctx)
+	if err != nil {
+		return cluster, handleStartError(ctx, cluster, err)
+	}
+
+	gates := append([]ReadinessGate{DefaultServiceAccountGate}, options.enabledReadinessGates()...)
+	err = waitForReadiness(ctx, clientSet, gates, options.readinessTimeout)
+	if err != nil {
+		return cluster, handleStartError(ctx, cluster, err)
+	}
+	cluster.logger.Info("testcluster-go: Cluster was successfully created")
+
 	return cluster, nil
 }
 
@@ -272,85 +358,121 @@ func createDefaultRBACForSA(ctx context.Context, c *K3dCluster) (string, error)
 	return sa.Name, nil
 }
 
-func (c *K3dCluster) waitForDefaultSACreation(ctx context.Context) error {
-	errRetriable := true
-
-	err := retry.OnError(wait.Backoff{
-		Steps:    20,
-		Duration: 500 * time.Millisecond,
-		Factor:   1.0,
-		Jitter:   0.1,
-	}, func(err error) bool {
-		return errRetriable
-	}, func() error {
-		clientset, err := c.ClientSet()
-		if err != nil {
-			errRetriable = false
-			return err
-		}
-
-		_, err = clientset.CoreV1().ServiceAccounts(DefaultNamespace).Get(ctx, "default", metav1.GetOptions{})
-		if err != nil {
-			l.Log().Info("testcluster-go: no default SA found")
-			return err
-		}
-		l.Log().Info("testcluster-go: found default SA")
-		return nil
-	})
+// keepForPostMortem writes the cluster's kubeconfig to a well-known file and tells the developer how to inspect and
+// delete the remaining cluster.
+func keepForPostMortem(t *testing.T, cluster *K3dCluster) error {
+	kubeConfigPath := KeptKubeConfigPath(cluster.ClusterName)
+	err := os.MkdirAll(filepath.Dir(kubeConfigPath), 0o700)
+	if err != nil {
+		return fmt.Errorf("could not create kubeconfig directory: %w", err)
+	}
 
+	err = clientcmd.WriteToFile(*cluster.kubeConfig, kubeConfigPath)
 	if err != nil {
-		return fmt.Errorf("waited too long for default SA: %w", err)
+		return fmt.Errorf("could not write kubeconfig: %w", err)
 	}
+
+	t.Logf(`testcluster-go: the test failed, cluster %s is kept for debugging. Inspect it with:
+    export KUBECONFIG=%s
+    kubectl get all --all-namespaces
+Delete it afterwards with:
+    testclusters delete %s
+or prune all clusters of exited test processes with:
+    testclusters prune`, cluster.ClusterName, kubeConfigPath, cluster.ClusterName)
+
 	return nil
 }
 
+// KeptKubeConfigPath returns the path of the kubeconfig file that is written for a cluster which is kept after a test
+// failure.
+func KeptKubeConfigPath(clusterName string) string {
+	return filepath.Join(os.TempDir(), "testclusters", clusterName, "kubeconfig.yaml")
+}
+
 func handleStartError(ctx context.Context, cluster *K3dCluster, err error) error {
-	err2 := cluster.Terminate(ctx)
+	// the start-up may have failed because ctx is done, but the started containers must be deleted anyway
+	teardownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), startErrorTeardownTimeout)
+	defer cancel()
+
+	err2 := cluster.Terminate(teardownCtx)
 	if err2 != nil {
-		l.Log().Errorf("Another error '%s' occurred while terminating the cluster due to the original error (you may want to clean-up the container landscape): %s", err2.Error(), err)
+		cluster.logger.Error(fmt.Sprintf("Another error '%s' occurred while terminating the cluster due to the original error (you may want to clean-up the container landscape): %s", err2.Error(), err))
 	}
 
 	return err
 }
 
-// Terminate shuts down the configured cluster.
+// Terminate shuts down the configured cluster. Subsequent calls return the result of the first call, unless that call
+// failed because its context was done; then the next call tries again.
 func (c *K3dCluster) Terminate(ctx context.Context) error {
+	c.terminateMu.Lock()
+	defer c.terminateMu.Unlock()
+	if c.terminateDone {
+		return c.terminateErr
+	}
+
+	c.terminated.Store(true)
+	defer captureK3dLogs(c.ClusterName, c.logger)()
+
 	err := client.ClusterDelete(ctx, c.containerRuntime, &c.clusterConfig.Cluster, k3dTypes.ClusterDeleteOpts{})
-	if err != nil {
-		return err
+	if err != nil && ctx.Err() != nil {
+		return fmt.Errorf("could not terminate cluster %s: %w", c.ClusterName, errors.Join(err, ctx.Err()))
 	}
 
-	return nil
+	c.terminateDone = true
+	c.terminateErr = err
+	unregisterLiveCluster(c)
+	c.releaseSlot()
+	return c.terminateErr
 }
 
 // ClientSet returns a K8s clientset which allows to interoperate with the cluster K8s API.
 func (c *K3dCluster) ClientSet() (*kubernetes.Clientset, error) {
-	if c.kubeConfig == nil {
+	if c.kubeConfig == nil || c.clientConfig == nil {
 		panic("cluster kubeConfig went unexpectedly nil")
 	}
-	intermediateConfig := clientcmd.NewDefaultClientConfig(*c.kubeConfig, nil)
-	clientConfig, err := intermediateConfig.ClientConfig()
+
+	clientSet, err := kubernetes.NewForConfig(c.clientConfig)
 	if err != nil {
 		return nil, err
 	}
-	c.clientConfig = clientConfig
 
-	clientSet, err := kubernetes.NewForConfig(clientConfig)
+	return clientSet, nil
+}
+
+// CommandExecutor returns an executor for shell commands in the cluster's pods which logs to the cluster's logger.
+func (c *K3dCluster) CommandExecutor() (*defaultCommandExecutor, error) {
+	clientSet, err := c.ClientSet()
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to create command executor: %w", err)
 	}
 
-	return clientSet, nil
+	executor := NewCommandExecutor(clientSet, clientSet.CoreV1().RESTClient())
+	executor.logger = c.logger
+	return executor, nil
 }
 
-func (c *K3dCluster) CtlKube(fieldManager string) (*YamlApplier, error) {
-	yamlApplier, err := NewYamlApplier(c.clientConfig, fieldManager, DefaultNamespace)
+// CtlKube returns an applier for YAML manifests. Namespaced resources without namespace are applied to the default
+// namespace unless another namespace is chosen with WithNamespace.
+func (c *K3dCluster) CtlKube(fieldManager string, opts ...ApplierOption) (*YamlApplier, error) {
+	yamlApplier, err := NewYamlApplier(c.clientConfig, fieldManager, DefaultNamespace, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("ctlkube call failed: %w", err)
 	}
+	yamlApplier.clusterName = c.ClusterName
+	yamlApplier.registry = c.Registry()
 	return yamlApplier, nil
 }
 
+// Registry returns the host and port of the cluster's image registry as seen from inside the cluster. Images that are
+// pushed to the registry can be used by pods under the reference `<registry>/<image>`.
+func (c *K3dCluster) Registry() string {
+	if c.clusterConfig == nil || c.clusterConfig.ClusterCreateOpts.Registries.Create == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s:%s", c.clusterConfig.ClusterCreateOpts.Registries.Create.Host, k3dTypes.DefaultRegistryPort)
+}
+
 type Lookout struct {
 	t *testing.T
 	c kubernetes.Interface
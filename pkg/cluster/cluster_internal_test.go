@@ -0,0 +1,97 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/k3d-io/k3d/v5/pkg/config/v1alpha5"
+	"github.com/k3d-io/k3d/v5/pkg/runtimes"
+	k3dTypes "github.com/k3d-io/k3d/v5/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// deleteRecordingRuntime records the context errors of the node lookups with which k3d starts to delete a cluster. The
+// cluster has no nodes, so each deletion fails. Other runtime methods must not be called.
+type deleteRecordingRuntime struct {
+	runtimes.Runtime
+	ctxErrs []error
+}
+
+func (f *deleteRecordingRuntime) GetNodesByLabel(ctx context.Context, _ map[string]string) ([]*k3dTypes.Node, error) {
+	f.ctxErrs = append(f.ctxErrs, ctx.Err())
+	return nil, nil
+}
+
+func newTerminatableCluster(containerRuntime runtimes.Runtime) (*K3dCluster, *int) {
+	released := 0
+	cluster := &K3dCluster{
+		containerRuntime: containerRuntime,
+		clusterConfig:    &v1alpha5.ClusterConfig{Cluster: k3dTypes.Cluster{Name: "hello-world-1234abcd"}},
+		ClusterName:      "hello-world-1234abcd",
+		logger:           slog.New(slog.NewTextHandler(io.Discard, nil)),
+		releaseSlot:      func() { released++ },
+	}
+	return cluster, &released
+}
+
+func Test_handleStartError(t *testing.T) {
+	// given
+	containerRuntime := &deleteRecordingRuntime{}
+	cluster, _ := newTerminatableCluster(containerRuntime)
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	startErr := errors.New("readiness gates not passed")
+
+	// when
+	err := handleStartError(ctx, cluster, startErr)
+
+	// then
+	assert.Same(t, startErr, err)
+	require.Len(t, containerRuntime.ctxErrs, 1)
+	assert.NoError(t, containerRuntime.ctxErrs[0])
+}
+
+func TestK3dCluster_Terminate(t *testing.T) {
+	t.Run("should try again after a failure caused by the context", func(t *testing.T) {
+		// given
+		containerRuntime := &deleteRecordingRuntime{}
+		cluster, released := newTerminatableCluster(containerRuntime)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		// when
+		err := cluster.Terminate(ctx)
+
+		// then
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Equal(t, 0, *released)
+
+		// when
+		err = cluster.Terminate(context.Background())
+
+		// then
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, context.Canceled)
+		assert.Equal(t, 1, *released)
+		assert.Equal(t, []error{context.Canceled, nil}, containerRuntime.ctxErrs)
+	})
+	t.Run("should return the result of the first completed deletion", func(t *testing.T) {
+		// given
+		containerRuntime := &deleteRecordingRuntime{}
+		cluster, released := newTerminatableCluster(containerRuntime)
+		firstErr := cluster.Terminate(context.Background())
+
+		// when
+		err := cluster.Terminate(context.Background())
+
+		// then
+		assert.Equal(t, firstErr, err)
+		assert.Equal(t, 1, *released)
+		assert.Len(t, containerRuntime.ctxErrs, 1)
+	})
+}
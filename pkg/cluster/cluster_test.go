@@ -58,3 +58,36 @@ func TestIntegration(t *testing.T) {
 	// then
 	assert.NoError(t, err)
 }
+
+func TestParallelClusters(t *testing.T) {
+	cluster.SetMaxConcurrentClusters(2)
+	t.Cleanup(func() {
+		cluster.SetMaxConcurrentClusters(0)
+	})
+
+	for i := 0; i < 3; i++ {
+		t.Run(fmt.Sprintf("cluster %d", i), func(t *testing.T) {
+			t.Parallel()
+
+			// given
+			cl := cluster.NewK3dCluster(t)
+			ctx := context.Background()
+
+			kubectl, err := cl.CtlKube(t.Name())
+			require.NoError(t, err)
+
+			// when
+			err = kubectl.ApplyWithFile(ctx, simpleEchoPodBytes)
+			require.NoError(t, err)
+
+			// then
+			pods := cl.Lookout(t).Pods(cluster.DefaultNamespace).ByLabels("app=echo-pod").List()
+			assert.EventuallyWithT(t, func(collectT *assert.CollectT) {
+				err := pods.Len(ctx, 1)
+				if err != nil {
+					collectT.Errorf("%w", err)
+				}
+			}, 60*time.Second, 1*time.Second)
+		})
+	}
+}
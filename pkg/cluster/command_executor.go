@@ -5,7 +5,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	l "github.com/k3d-io/k3d/v5/pkg/logger"
+	"log/slog"
 	"net/url"
 	"strings"
 	"time"
@@ -69,6 +69,7 @@ type defaultCommandExecutor struct {
 	clientSet              kubernetes.Interface
 	coreV1RestClient       rest.Interface
 	commandExecutorCreator func(config *rest.Config, method string, url *url.URL) (remotecommand.Executor, error)
+	logger                 *slog.Logger
 }
 
 // NewCommandExecutor creates a new instance of NewCommandExecutor
@@ -78,6 +79,7 @@ func NewCommandExecutor(clientSet kubernetes.Interface, coreV1RestClient rest.In
 		// the rest clientSet COULD be generated from the clientSet but makes harder to test, so we source it additionally
 		coreV1RestClient:       coreV1RestClient,
 		commandExecutorCreator: remotecommand.NewSPDYExecutor,
+		logger:                 slog.Default(),
 	}
 }
 
@@ -157,16 +159,14 @@ func (ce *defaultCommandExecutor) waitForPodToHaveExpectedStatus(ctx context.Con
 		if err != nil {
 			return err
 		}
-		return podHasStatus(pod, expectedPodStatus)
+		return podHasStatus(ce.logger, pod, expectedPodStatus)
 	})
 
 	return err
 }
 
-func podHasStatus(pod *corev1.Pod, expectedPodStatus string) error {
-	l.Log().Info("===== =====")
-	l.Log().Infof("podHasStatus %#v", pod)
-	l.Log().Info("===== =====")
+func podHasStatus(logger *slog.Logger, pod *corev1.Pod, expectedPodStatus string) error {
+	logger.Debug("podHasStatus", "pod", pod.Name, "phase", pod.Status.Phase)
 
 	switch expectedPodStatus {
 	case "started":
@@ -183,9 +183,7 @@ func podHasStatus(pod *corev1.Pod, expectedPodStatus string) error {
 		return fmt.Errorf("unsupported pod status: %s", expectedPodStatus)
 	}
 
-	l.Log().Info("===== =====")
-	l.Log().Infof("expectedPodStatus status %s not fulfilled", expectedPodStatus)
-	l.Log().Info("===== =====")
+	logger.Debug(fmt.Sprintf("expectedPodStatus status %s not fulfilled", expectedPodStatus), "pod", pod.Name)
 	return &TestableRetrierError{Err: fmt.Errorf("expectedPodStatus status %s not fulfilled", expectedPodStatus)}
 }
 
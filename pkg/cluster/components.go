@@ -0,0 +1,95 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/k3d-io/k3d/v5/pkg/config/v1alpha5"
+)
+
+// startupFieldManager is the field manager of resources that are applied during the cluster start-up.
+const startupFieldManager = "testclusters-go"
+
+// K3sComponent names a component that k3s deploys by default.
+type K3sComponent string
+
+// Components that k3s deploys by default and that can be disabled with WithDisabledComponents.
+const (
+	ComponentCoreDNS       K3sComponent = "coredns"
+	ComponentServiceLB     K3sComponent = "servicelb"
+	ComponentTraefik       K3sComponent = "traefik"
+	ComponentLocalStorage  K3sComponent = "local-storage"
+	ComponentMetricsServer K3sComponent = "metrics-server"
+)
+
+// componentGates maps components to the names of the readiness gates that wait for them.
+var componentGates = map[K3sComponent]string{
+	ComponentCoreDNS:       CoreDNSGate.Name,
+	ComponentTraefik:       TraefikGate.Name,
+	ComponentLocalStorage:  LocalPathProvisionerGate.Name,
+	ComponentMetricsServer: MetricsServerGate.Name,
+}
+
+// WithDisabledComponents prevents k3s from deploying the given components. This speeds up the cluster start-up for
+// tests that do not need them. Readiness gates of disabled components are skipped.
+func WithDisabledComponents(components ...K3sComponent) ClusterOption {
+	return func(opts *clusterOptions) {
+		opts.disabledComponents = append(opts.disabledComponents, components...)
+	}
+}
+
+// WithStartupManifests applies the given YAML manifests after the cluster has started and before the readiness gates
+// are checked, f. e. to replace a disabled component with another ingress controller. Resources without namespace are
+// created in the default namespace.
+func WithStartupManifests(manifests ...[]byte) ClusterOption {
+	return func(opts *clusterOptions) {
+		opts.startupManifests = append(opts.startupManifests, manifests...)
+	}
+}
+
+func (opts *clusterOptions) disabledComponentArgs() []v1alpha5.K3sArgWithNodeFilters {
+	var args []v1alpha5.K3sArgWithNodeFilters
+	for _, component := range opts.disabledComponents {
+		args = append(args, v1alpha5.K3sArgWithNodeFilters{
+			Arg:         "--disable=" + string(component),
+			NodeFilters: []string{allServers},
+		})
+	}
+	return args
+}
+
+// enabledReadinessGates returns the configured readiness gates without those that wait for disabled components.
+func (opts *clusterOptions) enabledReadinessGates() []ReadinessGate {
+	skipped := map[string]bool{}
+	for _, component := range opts.disabledComponents {
+		if gateName, ok := componentGates[component]; ok {
+			skipped[gateName] = true
+		}
+	}
+
+	var gates []ReadinessGate
+	for _, gate := range opts.readinessGates {
+		if !skipped[gate.Name] {
+			gates = append(gates, gate)
+		}
+	}
+	return gates
+}
+
+func (c *K3dCluster) applyStartupManifests(ctx context.Context) error {
+	if len(c.options.startupManifests) == 0 {
+		return nil
+	}
+
+	applier, err := NewYamlApplier(c.clientConfig, startupFieldManager, DefaultNamespace)
+	if err != nil {
+		return err
+	}
+	for i, manifest := range c.options.startupManifests {
+		err = applier.ApplyWithFile(ctx, manifest)
+		if err != nil {
+			return fmt.Errorf("failed to apply startup manifest %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package cluster
+
+import (
+	"testing"
+
+	"github.com/k3d-io/k3d/v5/pkg/config/v1alpha5"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_newSimpleConfig_disabledComponents(t *testing.T) {
+	// given
+	options := newClusterOptions(WithDisabledComponents(ComponentTraefik, ComponentServiceLB))
+
+	// when
+	config := newSimpleConfig("my-cluster", K3sVersion1_28, 6443, options)
+
+	// then
+	expected := []v1alpha5.K3sArgWithNodeFilters{
+		{Arg: "--disable=traefik", NodeFilters: []string{"server:*"}},
+		{Arg: "--disable=servicelb", NodeFilters: []string{"server:*"}},
+	}
+	assert.Equal(t, expected, config.Options.K3sOptions.ExtraArgs)
+}
+
+func Test_clusterOptions_enabledReadinessGates(t *testing.T) {
+	// given
+	options := newClusterOptions(
+		WithReadinessGates(SystemComponentGates()...),
+		WithDisabledComponents(ComponentTraefik, ComponentMetricsServer, ComponentServiceLB),
+	)
+
+	// when
+	gates := options.enabledReadinessGates()
+
+	// then
+	var names []string
+	for _, gate := range gates {
+		names = append(names, gate.Name)
+	}
+	assert.Equal(t, []string{"kube-system/coredns", "kube-system/local-path-provisioner"}, names)
+}
+
+func TestWithStartupManifests(t *testing.T) {
+	// when
+	options := newClusterOptions(WithStartupManifests([]byte("a")), WithStartupManifests([]byte("b"), []byte("c")))
+
+	// then
+	assert.Equal(t, [][]byte{[]byte("a"), []byte("b"), []byte("c")}, options.startupManifests)
+}
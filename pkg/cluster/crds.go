@@ -0,0 +1,114 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const crdEstablishedTimeout = time.Minute
+
+const crdGroupKind = "CustomResourceDefinition.apiextensions.k8s.io"
+
+// crdFieldManager is the field manager of CustomResourceDefinitions that are installed with InstallCRDs.
+const crdFieldManager = "testclusters-go-crds"
+
+// InstallCRDs applies the CustomResourceDefinitions in the given YAML files or directories and waits until they are
+// established and their kinds are served by the API server. Afterward, custom resources of these kinds can be applied.
+func (c *K3dCluster) InstallCRDs(ctx context.Context, paths ...string) error {
+	var documents []yamlDocument
+	for _, path := range paths {
+		pathDocuments, err := readYamlPath(path)
+		if err != nil {
+			return err
+		}
+		documents = append(documents, pathDocuments...)
+	}
+	return c.installCRDs(ctx, documents)
+}
+
+// InstallCRDsFS applies the CustomResourceDefinitions in the YAML files of a file system like InstallCRDs. See
+// YamlApplier.ApplyFS for the patterns.
+func (c *K3dCluster) InstallCRDsFS(ctx context.Context, fsys fs.FS, patterns ...string) error {
+	documents, err := readYamlFS(fsys, patterns)
+	if err != nil {
+		return err
+	}
+	return c.installCRDs(ctx, documents)
+}
+
+func (c *K3dCluster) installCRDs(ctx context.Context, documents []yamlDocument) error {
+	applier, err := NewYamlApplier(c.clientConfig, crdFieldManager, DefaultNamespace)
+	if err != nil {
+		return err
+	}
+	return applier.installCRDs(ctx, documents, crdEstablishedTimeout)
+}
+
+func (ya *YamlApplier) installCRDs(ctx context.Context, documents []yamlDocument, timeout time.Duration) error {
+	for _, document := range documents {
+		if document.object.GroupVersionKind().GroupKind().String() != crdGroupKind {
+			return fmt.Errorf("%s is not a CustomResourceDefinition", document)
+		}
+	}
+
+	err := ya.applyDocuments(ctx, documents)
+	if err != nil {
+		return err
+	}
+	err = ya.waitForDocuments(ctx, documents, timeout)
+	if err != nil {
+		return err
+	}
+	return ya.waitForDiscovery(ctx, documents, timeout)
+}
+
+// waitForDiscovery refreshes the discovery information until the kinds of all given CustomResourceDefinitions are
+// known. The API server serves new kinds shortly after their CustomResourceDefinition is established.
+func (ya *YamlApplier) waitForDiscovery(ctx context.Context, crds []yamlDocument, timeout time.Duration) error {
+	var kinds []schema.GroupVersionKind
+	for _, crd := range crds {
+		kinds = append(kinds, servedKinds(crd.object)...)
+	}
+
+	var missing []string
+	err := wait.PollUntilContextTimeout(ctx, resourceReadinessPollInterval, timeout, true, func(ctx context.Context) (bool, error) {
+		ya.mapper.Reset()
+		missing = nil
+		for _, kind := range kinds {
+			_, err := ya.mapper.RESTMapping(kind.GroupKind(), kind.Version)
+			if err != nil {
+				missing = append(missing, kind.String())
+			}
+		}
+		return len(missing) == 0, nil
+	})
+	if err != nil {
+		return fmt.Errorf("kinds are not served by the API server within %s: %s: %w", timeout, strings.Join(missing, ", "), err)
+	}
+	return nil
+}
+
+// servedKinds returns the kind of a CustomResourceDefinition in all served versions.
+func servedKinds(crd *unstructured.Unstructured) []schema.GroupVersionKind {
+	group, _, _ := unstructured.NestedString(crd.Object, "spec", "group")
+	kind, _, _ := unstructured.NestedString(crd.Object, "spec", "names", "kind")
+	versions, _, _ := unstructured.NestedSlice(crd.Object, "spec", "versions")
+
+	var kinds []schema.GroupVersionKind
+	for _, version := range versions {
+		fields, ok := version.(map[string]any)
+		if !ok || fields["served"] != true {
+			continue
+		}
+		name, _ := fields["name"].(string)
+		kinds = append(kinds, schema.GroupVersionKind{Group: group, Version: name, Kind: kind})
+	}
+	return kinds
+}
@@ -0,0 +1,164 @@
+package cluster
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/cloudogu/k8s-apply-lib/apply"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const gadgetCRD = `
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: gadgets.example.com
+spec:
+  group: example.com
+  names:
+    kind: Gadget
+    plural: gadgets
+  scope: Namespaced
+  versions:
+    - name: v1
+      served: true
+      storage: true
+    - name: v1alpha1
+      served: false
+      storage: false
+`
+
+// discoveringMapper learns the kinds of new CustomResourceDefinitions after the given number of resets.
+type discoveringMapper struct {
+	*meta.DefaultRESTMapper
+	resetsUntilServed int
+}
+
+func (m *discoveringMapper) Reset() {
+	m.resetsUntilServed--
+	if m.resetsUntilServed == 0 {
+		m.Add(schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Gadget"}, meta.RESTScopeNamespace)
+	}
+}
+
+func newDiscoveringMapper(resetsUntilServed int) *discoveringMapper {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Group: "apiextensions.k8s.io", Version: "v1", Kind: "CustomResourceDefinition"}, meta.RESTScopeRoot)
+	return &discoveringMapper{DefaultRESTMapper: mapper, resetsUntilServed: resetsUntilServed}
+}
+
+func TestYamlApplier_installCRDs(t *testing.T) {
+	establishedCRD := `{apiVersion: apiextensions.k8s.io/v1, kind: CustomResourceDefinition, metadata: {name: gadgets.example.com}, status: {conditions: [{type: Established, status: "True"}]}}`
+
+	t.Run("should wait until the CRD is established and served", func(t *testing.T) {
+		// given
+		documents, err := splitYamlDocuments("", []byte(gadgetCRD))
+		require.NoError(t, err)
+		applier := &recordingApplier{}
+		mapper := newDiscoveringMapper(2)
+		client := newTestDynamicClient(parseTestObject(t, establishedCRD))
+		sut := &YamlApplier{applier: applier, dynamicClient: client, mapper: mapper, defaultNamespace: "default"}
+
+		// when
+		err = sut.installCRDs(context.Background(), documents, 5*time.Second)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, []string{"gadgets.example.com"}, appliedNames(applier))
+		_, err = mapper.RESTMapping(schema.GroupKind{Group: "example.com", Kind: "Gadget"}, "v1")
+		assert.NoError(t, err)
+	})
+	t.Run("should fail if the kind is not served", func(t *testing.T) {
+		// given
+		documents, err := splitYamlDocuments("", []byte(gadgetCRD))
+		require.NoError(t, err)
+		client := newTestDynamicClient(parseTestObject(t, establishedCRD))
+		sut := &YamlApplier{applier: &recordingApplier{}, dynamicClient: client, mapper: newDiscoveringMapper(-1), defaultNamespace: "default"}
+
+		// when
+		err = sut.installCRDs(context.Background(), documents, time.Second)
+
+		// then
+		assert.ErrorContains(t, err, "kinds are not served by the API server within 1s: example.com/v1, Kind=Gadget")
+	})
+	t.Run("should reject other resources", func(t *testing.T) {
+		// given
+		documents, err := splitYamlDocuments("crds.yaml", []byte(gadgetCRD+"---\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: config\n"))
+		require.NoError(t, err)
+		applier := &recordingApplier{}
+		sut := &YamlApplier{applier: applier, defaultNamespace: "default"}
+
+		// when
+		err = sut.installCRDs(context.Background(), documents, time.Second)
+
+		// then
+		assert.EqualError(t, err, "document 2 (ConfigMap config) of crds.yaml is not a CustomResourceDefinition")
+		assert.Empty(t, applier.applied)
+	})
+}
+
+func Test_readYamlPath(t *testing.T) {
+	t.Run("should read a single file", func(t *testing.T) {
+		documents, err := readYamlPath("testdata/simpleEchoPod.yaml")
+
+		require.NoError(t, err)
+		require.Len(t, documents, 1)
+		assert.Equal(t, "document 1 (Pod echo-pod) of simpleEchoPod.yaml", documents[0].String())
+	})
+	t.Run("should read a single file with glob characters in its name", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "gadget[v1].yaml")
+		require.NoError(t, os.WriteFile(path, []byte(gadgetCRD), 0o644))
+
+		documents, err := readYamlPath(path)
+
+		require.NoError(t, err)
+		require.Len(t, documents, 1)
+		assert.Equal(t, "document 1 (CustomResourceDefinition gadgets.example.com) of gadget[v1].yaml", documents[0].String())
+	})
+	t.Run("should read a directory", func(t *testing.T) {
+		documents, err := readYamlPath("testdata")
+
+		require.NoError(t, err)
+		assert.Len(t, documents, 4)
+	})
+	t.Run("should read a file system", func(t *testing.T) {
+		fsys := fstest.MapFS{"crds/gadget.yaml": {Data: []byte(gadgetCRD)}}
+
+		documents, err := readYamlFS(fsys, nil)
+
+		require.NoError(t, err)
+		assert.Equal(t, "document 1 (CustomResourceDefinition gadgets.example.com) of crds/gadget.yaml", documents[0].String())
+	})
+}
+
+// noMatchApplier fails with an unknown kind until it is replaced.
+type noMatchApplier struct{}
+
+func (noMatchApplier) Apply(_ apply.YamlDocument, _ string) error {
+	return &meta.NoKindMatchError{GroupKind: schema.GroupKind{Group: "example.com", Kind: "Gadget"}}
+}
+
+func TestYamlApplier_ApplyWithFile_refreshesDiscovery(t *testing.T) {
+	// given
+	applier := &recordingApplier{}
+	sut := &YamlApplier{
+		applier:          noMatchApplier{},
+		newApplier:       func() (kubeApplier, error) { return applier, nil },
+		mapper:           newDiscoveringMapper(1),
+		defaultNamespace: "default",
+	}
+
+	// when
+	err := sut.ApplyWithFile(context.Background(), []byte("apiVersion: example.com/v1\nkind: Gadget\nmetadata:\n  name: my-gadget\n"))
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, []string{"my-gadget"}, appliedNames(applier))
+}
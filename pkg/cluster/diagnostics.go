@@ -0,0 +1,220 @@
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"text/tabwriter"
+	"time"
+
+	k3dTypes "github.com/k3d-io/k3d/v5/pkg/types"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+)
+
+// DiagnosticsDirEnv names the environment variable that contains the directory where diagnostics of failed tests are
+// written to. Each test receives its own subdirectory. If the variable is empty the diagnostics go to the test log.
+const DiagnosticsDirEnv = "TESTCLUSTERS_DIAGNOSTICS_DIR"
+
+const diagnosticsTimeout = 2 * time.Minute
+
+// diagnosticsSink receives a single diagnostics report.
+type diagnosticsSink func(name string, content []byte) error
+
+// DumpDiagnostics writes descriptions of all pods and nodes, the cluster events, all container logs (including those of
+// previous container instances) and the logs of the k3s servers into the given directory.
+func (c *K3dCluster) DumpDiagnostics(ctx context.Context, dir string) error {
+	return c.collectDiagnostics(ctx, dirSink(dir))
+}
+
+func dumpDiagnosticsOnFailure(t *testing.T, cluster *K3dCluster) {
+	if !t.Failed() || cluster.kubeConfig == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), diagnosticsTimeout)
+	defer cancel()
+
+	sink := testLogSink(t)
+	if dir := os.Getenv(DiagnosticsDirEnv); dir != "" {
+		testDir := filepath.Join(dir, sanitizeTestPath(t.Name()))
+		sink = dirSink(testDir)
+		t.Logf("testcluster-go: writing diagnostics of cluster %s to %s", cluster.ClusterName, testDir)
+	}
+
+	err := cluster.collectDiagnostics(ctx, sink)
+	if err != nil {
+		t.Logf("testcluster-go: diagnostics of cluster %s are incomplete: %s", cluster.ClusterName, err.Error())
+	}
+}
+
+func (c *K3dCluster) collectDiagnostics(ctx context.Context, sink diagnosticsSink) error {
+	clientSet, err := c.ClientSet()
+	if err != nil {
+		return fmt.Errorf("could not build clientSet for diagnostics: %w", err)
+	}
+
+	return errors.Join(
+		collectClusterDiagnostics(ctx, clientSet, sink),
+		c.collectServerLogs(ctx, sink),
+	)
+}
+
+func collectClusterDiagnostics(ctx context.Context, clientSet kubernetes.Interface, sink diagnosticsSink) error {
+	var errs []error
+
+	nodes, err := clientSet.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err == nil {
+		for i := range nodes.Items {
+			nodes.Items[i].ManagedFields = nil
+		}
+		err = sinkYaml(sink, "nodes.yaml", nodes)
+	}
+	errs = append(errs, err)
+
+	pods, err := clientSet.CoreV1().Pods(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err == nil {
+		for i := range pods.Items {
+			pods.Items[i].ManagedFields = nil
+		}
+		errs = append(errs, sinkYaml(sink, "pods.yaml", pods))
+		errs = append(errs, collectContainerLogs(ctx, clientSet, pods.Items, sink))
+	}
+	errs = append(errs, err)
+
+	events, err := clientSet.CoreV1().Events(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err == nil {
+		err = sink("events.txt", formatEvents(events.Items))
+	}
+	errs = append(errs, err)
+
+	return errors.Join(errs...)
+}
+
+func collectContainerLogs(ctx context.Context, clientSet kubernetes.Interface, pods []v1.Pod, sink diagnosticsSink) error {
+	var errs []error
+	for _, pod := range pods {
+		podClient := clientSet.CoreV1().Pods(pod.Namespace)
+		statuses := append(append([]v1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+		for _, status := range statuses {
+			prefix := filepath.Join("logs", pod.Namespace, pod.Name, status.Name)
+
+			logs, err := podClient.GetLogs(pod.Name, &v1.PodLogOptions{Container: status.Name}).Do(ctx).Raw()
+			if err == nil {
+				err = sink(prefix+".log", logs)
+			}
+			errs = append(errs, err)
+
+			if status.RestartCount == 0 {
+				continue
+			}
+			logs, err = podClient.GetLogs(pod.Name, &v1.PodLogOptions{Container: status.Name, Previous: true}).Do(ctx).Raw()
+			if err == nil {
+				err = sink(prefix+".previous.log", logs)
+			}
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (c *K3dCluster) collectServerLogs(ctx context.Context, sink diagnosticsSink) error {
+	if c.clusterConfig == nil {
+		return nil
+	}
+
+	var errs []error
+	for _, node := range c.clusterConfig.Cluster.Nodes {
+		if node.Role != k3dTypes.ServerRole {
+			continue
+		}
+
+		logs := &bytes.Buffer{}
+		err := writeNodeLogs(ctx, c.containerRuntime, node, logs, false)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		errs = append(errs, sink(filepath.Join("k3s", node.Name+".log"), logs.Bytes()))
+	}
+	return errors.Join(errs...)
+}
+
+func formatEvents(events []v1.Event) []byte {
+	sort.SliceStable(events, func(i, j int) bool {
+		return eventTime(events[i]).Before(eventTime(events[j]))
+	})
+
+	buf := &bytes.Buffer{}
+	w := tabwriter.NewWriter(buf, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "TIME\tNAMESPACE\tTYPE\tREASON\tOBJECT\tMESSAGE")
+	for _, event := range events {
+		object := fmt.Sprintf("%s/%s", event.InvolvedObject.Kind, event.InvolvedObject.Name)
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			eventTime(event).Format(time.RFC3339), event.Namespace, event.Type, event.Reason, object, event.Message)
+	}
+	_ = w.Flush()
+
+	return buf.Bytes()
+}
+
+func eventTime(event v1.Event) time.Time {
+	if !event.LastTimestamp.IsZero() {
+		return event.LastTimestamp.Time
+	}
+	if !event.EventTime.IsZero() {
+		return event.EventTime.Time
+	}
+	return event.CreationTimestamp.Time
+}
+
+func sinkYaml(sink diagnosticsSink, name string, obj any) error {
+	content, err := yaml.Marshal(obj)
+	if err != nil {
+		return fmt.Errorf("could not marshal %s: %w", name, err)
+	}
+	return sink(name, content)
+}
+
+func dirSink(dir string) diagnosticsSink {
+	return func(name string, content []byte) error {
+		path := filepath.Join(dir, name)
+		err := os.MkdirAll(filepath.Dir(path), 0o755)
+		if err != nil {
+			return fmt.Errorf("could not create diagnostics directory: %w", err)
+		}
+		return os.WriteFile(path, content, 0o644)
+	}
+}
+
+func testLogSink(t *testing.T) diagnosticsSink {
+	return func(name string, content []byte) error {
+		t.Logf("===== %s =====\n%s", name, content)
+		return nil
+	}
+}
+
+var unsafePathChars = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+// sanitizeTestPath turns a test name into a relative path with one directory per subtest level. Parts like `..` are
+// replaced so that the path cannot leave the diagnostics directory.
+func sanitizeTestPath(testName string) string {
+	parts := strings.Split(testName, "/")
+	for i, part := range parts {
+		part = unsafePathChars.ReplaceAllString(part, "_")
+		if part == "" || part == "." || part == ".." {
+			part = strings.Repeat("_", max(len(part), 1))
+		}
+		parts[i] = part
+	}
+	return filepath.Join(parts...)
+}
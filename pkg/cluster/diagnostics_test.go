@@ -0,0 +1,93 @@
+package cluster
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_collectClusterDiagnostics(t *testing.T) {
+	// given
+	restartedPod := runningPod("nginx-1", "nginx")
+	restartedPod.Status.ContainerStatuses[0].RestartCount = 2
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "k3d-hello-world-server-0"}}
+	event := &corev1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Name: "nginx-1.event", Namespace: DefaultNamespace},
+		InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "nginx-1"},
+		Type:           corev1.EventTypeWarning,
+		Reason:         "BackOff",
+		Message:        "Back-off restarting failed container",
+	}
+	clientSet := fake.NewSimpleClientset(restartedPod, runningPod("echo-pod", "alpine"), node, event)
+
+	reports := map[string]string{}
+	sink := func(name string, content []byte) error {
+		reports[name] = string(content)
+		return nil
+	}
+
+	// when
+	err := collectClusterDiagnostics(context.Background(), clientSet, sink)
+
+	// then
+	require.NoError(t, err)
+	assert.Len(t, reports, 6)
+	assert.Contains(t, reports["nodes.yaml"], "name: k3d-hello-world-server-0")
+	assert.Contains(t, reports["pods.yaml"], "name: nginx-1")
+	assert.Contains(t, reports["pods.yaml"], "name: echo-pod")
+	assert.Contains(t, reports["events.txt"], "Pod/nginx-1")
+	assert.Contains(t, reports["events.txt"], "Back-off restarting failed container")
+	assert.Equal(t, "fake logs", reports[filepath.Join("logs", DefaultNamespace, "nginx-1", "nginx.log")])
+	assert.Equal(t, "fake logs", reports[filepath.Join("logs", DefaultNamespace, "nginx-1", "nginx.previous.log")])
+	assert.Equal(t, "fake logs", reports[filepath.Join("logs", DefaultNamespace, "echo-pod", "alpine.log")])
+}
+
+func Test_formatEvents(t *testing.T) {
+	// given
+	now := time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+	events := []corev1.Event{
+		{Reason: "Second", LastTimestamp: metav1.NewTime(now)},
+		{Reason: "First", LastTimestamp: metav1.NewTime(now.Add(-time.Minute))},
+	}
+
+	// when
+	actual := string(formatEvents(events))
+
+	// then
+	lines := strings.Split(strings.TrimSpace(actual), "\n")
+	require.Len(t, lines, 3)
+	assert.True(t, strings.HasPrefix(lines[0], "TIME"))
+	assert.Contains(t, lines[1], "First")
+	assert.Contains(t, lines[2], "Second")
+}
+
+func Test_sanitizeTestPath(t *testing.T) {
+	assert.Equal(t, filepath.Join("TestSomething", "with_spaces_and_stars"), sanitizeTestPath("TestSomething/with spaces:and*stars"))
+	assert.Equal(t, filepath.Join("TestSomething", "__", "_", "_", "v1.2"), sanitizeTestPath("TestSomething/.././/v1.2"))
+	assert.True(t, filepath.IsLocal(sanitizeTestPath("TestSomething/../../etc")))
+}
+
+func Test_dirSink(t *testing.T) {
+	// given
+	dir := filepath.Join(t.TempDir(), sanitizeTestPath("TestSomething/with spaces:and*stars"))
+	sut := dirSink(dir)
+
+	// when
+	err := sut(filepath.Join("logs", "default", "echo-pod", "alpine.log"), []byte("hello world\n"))
+
+	// then
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(dir, filepath.Join("TestSomething", "with_spaces_and_stars")))
+	actual, err := os.ReadFile(filepath.Join(dir, "logs", "default", "echo-pod", "alpine.log"))
+	require.NoError(t, err)
+	assert.Equal(t, "hello world\n", string(actual))
+}
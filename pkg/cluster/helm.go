@@ -0,0 +1,281 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/storage/driver"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+// helmStorageDriver stores releases in secrets like the helm CLI does.
+const helmStorageDriver = "secret"
+
+// cleanupUninstallTimeout limits the uninstallation of a release when the test finishes.
+const cleanupUninstallTimeout = 1 * time.Minute
+
+// HelmClient installs Helm charts into a cluster. Charts are loaded from local chart directories or chart archives,
+// chart repositories are not supported.
+type HelmClient struct {
+	cluster *K3dCluster
+	// newConfig creates the Helm configuration for a namespace. A positive request timeout limits each API request.
+	newConfig func(namespace string, requestTimeout time.Duration) (*action.Configuration, error)
+}
+
+// HelmOption customizes a Helm action.
+type HelmOption func(opts *helmOptions)
+
+type helmOptions struct {
+	namespace      string
+	wait           bool
+	timeout        time.Duration
+	requestTimeout time.Duration
+}
+
+// WithReleaseNamespace installs the release into the given namespace instead of the default namespace. The namespace
+// is created if it does not exist.
+func WithReleaseNamespace(namespace string) HelmOption {
+	return func(opts *helmOptions) {
+		opts.namespace = namespace
+	}
+}
+
+// WithHelmWait waits until all resources of the release are ready, or, on uninstall, deleted.
+func WithHelmWait(timeout time.Duration) HelmOption {
+	return func(opts *helmOptions) {
+		opts.wait = true
+		opts.timeout = timeout
+	}
+}
+
+// withHelmTimeout limits the action and each of its API requests to the given timeout without waiting for resources.
+func withHelmTimeout(timeout time.Duration) HelmOption {
+	return func(opts *helmOptions) {
+		opts.timeout = timeout
+		opts.requestTimeout = timeout
+	}
+}
+
+func newHelmOptions(opts []HelmOption) *helmOptions {
+	options := &helmOptions{namespace: DefaultNamespace, timeout: 5 * time.Minute}
+	for _, opt := range opts {
+		opt(options)
+	}
+	return options
+}
+
+// Helm returns a client which installs Helm charts into the cluster. Releases that are installed into a cluster of
+// NewK3dCluster are uninstalled when the test finishes, unless the cluster is kept for post-mortem debugging or was
+// already terminated.
+func (c *K3dCluster) Helm() *HelmClient {
+	return &HelmClient{cluster: c, newConfig: c.newHelmConfig}
+}
+
+// Install installs a chart from a chart directory or a `.tgz` archive as a new release.
+func (h *HelmClient) Install(ctx context.Context, releaseName, chartPath string, values map[string]any, opts ...HelmOption) (*release.Release, error) {
+	options := newHelmOptions(opts)
+	loadedChart, err := loadChart(chartPath)
+	if err != nil {
+		return nil, err
+	}
+	config, err := h.newConfig(options.namespace, options.requestTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	install := action.NewInstall(config)
+	install.ReleaseName = releaseName
+	install.Namespace = options.namespace
+	install.CreateNamespace = true
+	install.Wait = options.wait
+	install.Timeout = options.timeout
+
+	installed, err := install.RunWithContext(ctx, loadedChart, values)
+	if err != nil {
+		return nil, fmt.Errorf("failed to install release %s: %w", releaseName, err)
+	}
+	h.uninstallOnCleanup(releaseName, options.namespace)
+	return installed, nil
+}
+
+// Upgrade upgrades an existing release to the given chart and values.
+func (h *HelmClient) Upgrade(ctx context.Context, releaseName, chartPath string, values map[string]any, opts ...HelmOption) (*release.Release, error) {
+	options := newHelmOptions(opts)
+	loadedChart, err := loadChart(chartPath)
+	if err != nil {
+		return nil, err
+	}
+	config, err := h.newConfig(options.namespace, options.requestTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	upgrade := action.NewUpgrade(config)
+	upgrade.Namespace = options.namespace
+	upgrade.Wait = options.wait
+	upgrade.Timeout = options.timeout
+
+	upgraded, err := upgrade.RunWithContext(ctx, releaseName, loadedChart, values)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upgrade release %s: %w", releaseName, err)
+	}
+	return upgraded, nil
+}
+
+// Uninstall removes a release and all of its resources.
+func (h *HelmClient) Uninstall(ctx context.Context, releaseName string, opts ...HelmOption) error {
+	options := newHelmOptions(opts)
+	config, err := h.newConfig(options.namespace, options.requestTimeout)
+	if err != nil {
+		return err
+	}
+
+	uninstall := action.NewUninstall(config)
+	uninstall.Wait = options.wait
+	uninstall.Timeout = options.timeout
+
+	// the uninstall action does not accept a context
+	if ctx.Err() != nil {
+		return fmt.Errorf("failed to uninstall release %s: %w", releaseName, ctx.Err())
+	}
+	_, err = uninstall.Run(releaseName)
+	if err != nil {
+		return fmt.Errorf("failed to uninstall release %s: %w", releaseName, err)
+	}
+	return nil
+}
+
+// Template renders a chart like `helm template` for the Kubernetes version of the cluster and returns the manifest.
+// Nothing is installed.
+func (h *HelmClient) Template(ctx context.Context, releaseName, chartPath string, values map[string]any, opts ...HelmOption) ([]byte, error) {
+	options := newHelmOptions(opts)
+	loadedChart, err := loadChart(chartPath)
+	if err != nil {
+		return nil, err
+	}
+	config, err := h.newConfig(options.namespace, options.requestTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	install := action.NewInstall(config)
+	install.ReleaseName = releaseName
+	install.Namespace = options.namespace
+	install.DryRun = true
+	install.ClientOnly = true
+	install.Replace = true
+	install.IncludeCRDs = true
+	install.KubeVersion, err = h.kubeVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	rendered, err := install.RunWithContext(ctx, loadedChart, values)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render chart %s: %w", chartPath, err)
+	}
+	return []byte(rendered.Manifest), nil
+}
+
+func (h *HelmClient) uninstallOnCleanup(releaseName, namespace string) {
+	test := h.cluster.test
+	if test == nil {
+		return
+	}
+
+	test.Cleanup(func() {
+		if h.cluster.terminated.Load() || (test.Failed() && h.cluster.options.keepOnFailure) {
+			return
+		}
+		// an unresponsive cluster must not block the test's clean-up
+		ctx, cancel := context.WithTimeout(context.Background(), cleanupUninstallTimeout)
+		defer cancel()
+		err := h.Uninstall(ctx, releaseName, WithReleaseNamespace(namespace), withHelmTimeout(cleanupUninstallTimeout))
+		if err != nil && !errors.Is(err, driver.ErrReleaseNotFound) {
+			h.cluster.logger.Warn(fmt.Sprintf("testcluster-go: could not uninstall release %s: %s", releaseName, err.Error()))
+		}
+	})
+}
+
+// kubeVersion returns the Kubernetes version of the cluster without the k3s suffix.
+func (h *HelmClient) kubeVersion() (*chartutil.KubeVersion, error) {
+	k3sVersion, err := K3sVersionFor(h.cluster.options.kubernetesVersion)
+	if err != nil {
+		return nil, err
+	}
+	version, _, _ := strings.Cut(k3sVersion, "-")
+	return chartutil.ParseKubeVersion(version)
+}
+
+func loadChart(chartPath string) (*chart.Chart, error) {
+	loadedChart, err := loader.Load(chartPath)
+	if err != nil {
+		return nil, fmt.Errorf("could not load chart %s: %w", chartPath, err)
+	}
+	return loadedChart, nil
+}
+
+func (c *K3dCluster) newHelmConfig(namespace string, requestTimeout time.Duration) (*action.Configuration, error) {
+	config := &action.Configuration{}
+	getter := &helmRESTClientGetter{kubeConfig: c.kubeConfig, namespace: namespace, requestTimeout: requestTimeout}
+	err := config.Init(getter, namespace, helmStorageDriver, func(format string, v ...any) {
+		c.logger.Debug("testcluster-go: helm: " + fmt.Sprintf(format, v...))
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not configure helm: %w", err)
+	}
+	return config, nil
+}
+
+// helmRESTClientGetter provides Helm with clients for the cluster.
+type helmRESTClientGetter struct {
+	kubeConfig     *api.Config
+	namespace      string
+	requestTimeout time.Duration
+}
+
+func (g *helmRESTClientGetter) ToRESTConfig() (*rest.Config, error) {
+	return g.ToRawKubeConfigLoader().ClientConfig()
+}
+
+func (g *helmRESTClientGetter) ToDiscoveryClient() (discovery.CachedDiscoveryInterface, error) {
+	config, err := g.ToRESTConfig()
+	if err != nil {
+		return nil, err
+	}
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return memory.NewMemCacheClient(discoveryClient), nil
+}
+
+func (g *helmRESTClientGetter) ToRESTMapper() (meta.RESTMapper, error) {
+	discoveryClient, err := g.ToDiscoveryClient()
+	if err != nil {
+		return nil, err
+	}
+	return restmapper.NewDeferredDiscoveryRESTMapper(discoveryClient), nil
+}
+
+func (g *helmRESTClientGetter) ToRawKubeConfigLoader() clientcmd.ClientConfig {
+	overrides := &clientcmd.ConfigOverrides{Context: api.Context{Namespace: g.namespace}}
+	if g.requestTimeout > 0 {
+		overrides.Timeout = g.requestTimeout.String()
+	}
+	return clientcmd.NewDefaultClientConfig(*g.kubeConfig, overrides)
+}
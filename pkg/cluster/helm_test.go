@@ -0,0 +1,234 @@
+package cluster
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	kubefake "helm.sh/helm/v3/pkg/kube/fake"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/storage"
+	"helm.sh/helm/v3/pkg/storage/driver"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+func writeTestChart(t *testing.T) string {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "echo")
+	files := map[string]string{
+		"Chart.yaml":               "apiVersion: v2\nname: echo\nversion: 0.1.0\nkubeVersion: \">=1.20.0-0\"\n",
+		"values.yaml":              "message: hello\n",
+		"templates/configmap.yaml": "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: {{ .Release.Name }}\ndata:\n  message: {{ .Values.message }}\n  kubeVersion: {{ .Capabilities.KubeVersion.Version }}\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	return dir
+}
+
+type fakeTest struct {
+	cleanups []func()
+	failed   bool
+}
+
+func (f *fakeTest) Cleanup(cleanup func()) { f.cleanups = append(f.cleanups, cleanup) }
+func (f *fakeTest) Failed() bool           { return f.failed }
+
+// newTestHelmClient returns a Helm client that keeps releases in memory and does not contact a cluster.
+func newTestHelmClient(test testCleanup, opts ...ClusterOption) (*HelmClient, *storage.Storage) {
+	releases := storage.Init(driver.NewMemory())
+	cluster := &K3dCluster{options: newClusterOptions(opts...), logger: slog.New(slog.NewTextHandler(io.Discard, nil)), test: test}
+	return &HelmClient{
+		cluster: cluster,
+		newConfig: func(namespace string, _ time.Duration) (*action.Configuration, error) {
+			return &action.Configuration{
+				Releases:     releases,
+				KubeClient:   &kubefake.PrintingKubeClient{Out: io.Discard},
+				Capabilities: chartutil.DefaultCapabilities,
+				Log:          func(string, ...any) {},
+			}, nil
+		},
+	}, releases
+}
+
+func TestHelmClient(t *testing.T) {
+	t.Run("should install, upgrade and uninstall a chart directory", func(t *testing.T) {
+		// given
+		chartDir := writeTestChart(t)
+		sut, releases := newTestHelmClient(nil)
+		ctx := context.Background()
+
+		// when
+		installed, err := sut.Install(ctx, "echo", chartDir, nil, WithReleaseNamespace("web"))
+		require.NoError(t, err)
+		upgraded, err := sut.Upgrade(ctx, "echo", chartDir, map[string]any{"message": "bye"}, WithReleaseNamespace("web"))
+		require.NoError(t, err)
+
+		// then
+		assert.Equal(t, "web", installed.Namespace)
+		assert.Contains(t, installed.Manifest, "message: hello")
+		assert.Equal(t, 2, upgraded.Version)
+		assert.Contains(t, upgraded.Manifest, "message: bye")
+
+		// when
+		err = sut.Uninstall(ctx, "echo", WithReleaseNamespace("web"))
+
+		// then
+		require.NoError(t, err)
+		_, err = releases.Last("echo")
+		assert.ErrorIs(t, err, driver.ErrReleaseNotFound)
+	})
+	t.Run("should install a chart archive", func(t *testing.T) {
+		// given
+		loadedChart, err := loader.Load(writeTestChart(t))
+		require.NoError(t, err)
+		archive, err := chartutil.Save(loadedChart, t.TempDir())
+		require.NoError(t, err)
+		sut, _ := newTestHelmClient(nil)
+
+		// when
+		installed, err := sut.Install(context.Background(), "echo", archive, nil)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, DefaultNamespace, installed.Namespace)
+	})
+	t.Run("should not uninstall with a done context", func(t *testing.T) {
+		// given
+		sut, releases := newTestHelmClient(nil)
+		_, err := sut.Install(context.Background(), "echo", writeTestChart(t), nil)
+		require.NoError(t, err)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		// when
+		err = sut.Uninstall(ctx, "echo")
+
+		// then
+		assert.ErrorIs(t, err, context.Canceled)
+		_, err = releases.Last("echo")
+		assert.NoError(t, err)
+	})
+	t.Run("should fail for missing charts", func(t *testing.T) {
+		// given
+		sut, _ := newTestHelmClient(nil)
+
+		// when
+		_, err := sut.Install(context.Background(), "echo", "https://charts.example.com/echo", nil)
+
+		// then
+		assert.ErrorContains(t, err, "could not load chart https://charts.example.com/echo")
+	})
+	t.Run("should render templates for the cluster's Kubernetes version", func(t *testing.T) {
+		// given
+		sut, releases := newTestHelmClient(nil, WithKubernetesVersion("1.29"))
+
+		// when
+		manifest, err := sut.Template(context.Background(), "echo", writeTestChart(t), map[string]any{"message": "rendered"})
+
+		// then
+		require.NoError(t, err)
+		assert.Contains(t, string(manifest), "message: rendered")
+		assert.Contains(t, string(manifest), "kubeVersion: v1.29.7")
+		all, err := releases.ListReleases()
+		require.NoError(t, err)
+		assert.Empty(t, all)
+	})
+}
+
+func TestHelmClient_uninstallOnCleanup(t *testing.T) {
+	t.Run("should uninstall releases after the test", func(t *testing.T) {
+		// given
+		test := &fakeTest{}
+		sut, releases := newTestHelmClient(test)
+		_, err := sut.Install(context.Background(), "echo", writeTestChart(t), nil)
+		require.NoError(t, err)
+
+		// when
+		require.Len(t, test.cleanups, 1)
+		test.cleanups[0]()
+
+		// then
+		_, err = releases.Last("echo")
+		assert.ErrorIs(t, err, driver.ErrReleaseNotFound)
+	})
+	t.Run("should limit the uninstallation", func(t *testing.T) {
+		// given
+		test := &fakeTest{}
+		sut, _ := newTestHelmClient(test)
+		_, err := sut.Install(context.Background(), "echo", writeTestChart(t), nil)
+		require.NoError(t, err)
+		newConfig := sut.newConfig
+		var requestTimeout time.Duration
+		sut.newConfig = func(namespace string, timeout time.Duration) (*action.Configuration, error) {
+			requestTimeout = timeout
+			return newConfig(namespace, timeout)
+		}
+
+		// when
+		test.cleanups[0]()
+
+		// then
+		assert.Equal(t, cleanupUninstallTimeout, requestTimeout)
+	})
+	t.Run("should skip releases of terminated clusters", func(t *testing.T) {
+		// given
+		test := &fakeTest{}
+		sut, releases := newTestHelmClient(test)
+		_, err := sut.Install(context.Background(), "echo", writeTestChart(t), nil)
+		require.NoError(t, err)
+		sut.cluster.terminated.Store(true)
+
+		// when
+		test.cleanups[0]()
+
+		// then
+		last, err := releases.Last("echo")
+		require.NoError(t, err)
+		assert.Equal(t, release.StatusDeployed, last.Info.Status)
+	})
+	t.Run("should keep releases of failed tests whose cluster is kept", func(t *testing.T) {
+		// given
+		test := &fakeTest{failed: true}
+		sut, releases := newTestHelmClient(test, WithKeepOnFailure())
+		_, err := sut.Install(context.Background(), "echo", writeTestChart(t), nil)
+		require.NoError(t, err)
+
+		// when
+		test.cleanups[0]()
+
+		// then
+		last, err := releases.Last("echo")
+		require.NoError(t, err)
+		assert.Equal(t, release.StatusDeployed, last.Info.Status)
+	})
+}
+
+func Test_helmRESTClientGetter_ToRESTConfig(t *testing.T) {
+	// given
+	kubeConfig := &api.Config{
+		Clusters:       map[string]*api.Cluster{"k3d": {Server: "https://127.0.0.1:6443"}},
+		AuthInfos:      map[string]*api.AuthInfo{"admin": {}},
+		Contexts:       map[string]*api.Context{"k3d": {Cluster: "k3d", AuthInfo: "admin"}},
+		CurrentContext: "k3d",
+	}
+	sut := &helmRESTClientGetter{kubeConfig: kubeConfig, namespace: "web", requestTimeout: time.Minute}
+
+	// when
+	config, err := sut.ToRESTConfig()
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, time.Minute, config.Timeout)
+}
@@ -0,0 +1,143 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// HandleInterruptsEnv names the environment variable that enables HandleInterrupts with the creation of the first
+// cluster when set to a true value like `1` or `true`.
+const HandleInterruptsEnv = "TESTCLUSTERS_HANDLE_INTERRUPTS"
+
+// deadlineGracePeriod is the time before the deadline of `go test -timeout` at which all clusters are terminated. The
+// grace period is shortened for short timeouts, see gracefulDeadline.
+const deadlineGracePeriod = 30 * time.Second
+
+// interruptTeardownTimeout limits the time that is spent on terminating clusters after an interrupt.
+const interruptTeardownTimeout = 1 * time.Minute
+
+var (
+	liveClustersMu sync.Mutex
+	liveClusters   = map[*K3dCluster]struct{}{}
+
+	handleInterruptsOnce sync.Once
+	deadlineWatchOnce    sync.Once
+	interruptsHandled    bool
+)
+
+// HandleInterrupts terminates all clusters of this process when the process receives SIGINT or SIGTERM, f. e. when a
+// test run is aborted with Ctrl-C. Afterwards, the process exits. HandleInterrupts also terminates all clusters shortly
+// before the deadline of `go test -timeout` is reached because the test binary panics without running any clean-up.
+//
+// HandleInterrupts is usually called from TestMain. Calling it more than once has no further effect.
+func HandleInterrupts() {
+	handleInterruptsOnce.Do(func() {
+		liveClustersMu.Lock()
+		interruptsHandled = true
+		liveClustersMu.Unlock()
+
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			sig := <-signals
+			signal.Stop(signals)
+
+			_, _ = fmt.Fprintf(os.Stderr, "testcluster-go: received %s, terminating all clusters\n", sig)
+			terminateLiveClusters()
+			os.Exit(exitCode(sig))
+		}()
+	})
+}
+
+func handleInterruptsFromEnv() {
+	enabled, _ := strconv.ParseBool(os.Getenv(HandleInterruptsEnv))
+	if enabled {
+		HandleInterrupts()
+	}
+}
+
+// watchTestDeadline terminates all clusters shortly before the test binary panics because of `go test -timeout`.
+func watchTestDeadline(t *testing.T) {
+	liveClustersMu.Lock()
+	enabled := interruptsHandled
+	liveClustersMu.Unlock()
+
+	deadline, ok := t.Deadline()
+	if !enabled || !ok {
+		return
+	}
+
+	// all tests of a binary share the same deadline
+	deadlineWatchOnce.Do(func() {
+		time.AfterFunc(time.Until(gracefulDeadline(deadline)), func() {
+			_, _ = fmt.Fprintln(os.Stderr, "testcluster-go: test deadline is about to be exceeded, terminating all clusters")
+			terminateLiveClusters()
+		})
+	})
+}
+
+// gracefulDeadline returns the time at which clusters are terminated before the given test deadline. The grace period
+// takes at most a quarter of the remaining time so that short timeouts do not terminate clusters right away.
+func gracefulDeadline(deadline time.Time) time.Time {
+	gracePeriod := min(deadlineGracePeriod, time.Until(deadline)/4)
+	if gracePeriod < 0 {
+		return deadline
+	}
+	return deadline.Add(-gracePeriod)
+}
+
+func registerLiveCluster(cluster *K3dCluster) {
+	liveClustersMu.Lock()
+	defer liveClustersMu.Unlock()
+	liveClusters[cluster] = struct{}{}
+}
+
+func unregisterLiveCluster(cluster *K3dCluster) {
+	liveClustersMu.Lock()
+	defer liveClustersMu.Unlock()
+	delete(liveClusters, cluster)
+}
+
+func currentLiveClusters() []*K3dCluster {
+	liveClustersMu.Lock()
+	defer liveClustersMu.Unlock()
+
+	clusters := make([]*K3dCluster, 0, len(liveClusters))
+	for cluster := range liveClusters {
+		clusters = append(clusters, cluster)
+	}
+	return clusters
+}
+
+func terminateLiveClusters() {
+	ctx, cancel := context.WithTimeout(context.Background(), interruptTeardownTimeout)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for _, cluster := range currentLiveClusters() {
+		wg.Add(1)
+		go func(cluster *K3dCluster) {
+			defer wg.Done()
+			err := cluster.Terminate(ctx)
+			if err != nil {
+				_, _ = fmt.Fprintf(os.Stderr, "testcluster-go: could not terminate cluster %s: %s\n", cluster.ClusterName, err.Error())
+			}
+		}(cluster)
+	}
+	wg.Wait()
+}
+
+// exitCode follows the shell convention of 128 plus the signal number for processes that were ended by a signal.
+func exitCode(sig os.Signal) int {
+	if number, ok := sig.(syscall.Signal); ok {
+		return 128 + int(number)
+	}
+	return 1
+}
@@ -0,0 +1,49 @@
+package cluster
+
+import (
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_liveClusters(t *testing.T) {
+	// given
+	first := &K3dCluster{ClusterName: "first"}
+	second := &K3dCluster{ClusterName: "second"}
+
+	// when
+	registerLiveCluster(first)
+	registerLiveCluster(second)
+	registerLiveCluster(first)
+	unregisterLiveCluster(second)
+
+	// then
+	assert.Equal(t, []*K3dCluster{first}, currentLiveClusters())
+	unregisterLiveCluster(first)
+	assert.Empty(t, currentLiveClusters())
+}
+
+func Test_exitCode(t *testing.T) {
+	assert.Equal(t, 130, exitCode(os.Interrupt))
+	assert.Equal(t, 143, exitCode(syscall.SIGTERM))
+}
+
+func Test_gracefulDeadline(t *testing.T) {
+	t.Run("should keep the grace period for long timeouts", func(t *testing.T) {
+		deadline := time.Now().Add(10 * time.Minute)
+		assert.Equal(t, deadline.Add(-deadlineGracePeriod), gracefulDeadline(deadline))
+	})
+	t.Run("should shorten the grace period for short timeouts", func(t *testing.T) {
+		deadline := time.Now().Add(20 * time.Second)
+		actual := gracefulDeadline(deadline)
+		assert.WithinDuration(t, deadline.Add(-5*time.Second), actual, 100*time.Millisecond)
+		assert.True(t, actual.After(time.Now().Add(10*time.Second)))
+	})
+	t.Run("should keep a passed deadline", func(t *testing.T) {
+		deadline := time.Now().Add(-time.Second)
+		assert.Equal(t, deadline, gracefulDeadline(deadline))
+	})
+}
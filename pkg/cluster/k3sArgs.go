@@ -0,0 +1,96 @@
+package cluster
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/k3d-io/k3d/v5/pkg/config/v1alpha5"
+)
+
+// Node filters that select the nodes of a k3d cluster.
+const (
+	allServers = "server:*"
+	allAgents  = "agent:*"
+)
+
+// WithK3sArgs passes an argument like `--kube-apiserver-arg=v=4` to k3s on all nodes that match one of the given k3d
+// node filters, f. e. `server:0` or `agent:*`.
+func WithK3sArgs(arg string, nodeFilters ...string) ClusterOption {
+	return func(opts *clusterOptions) {
+		opts.extraArgs = append(opts.extraArgs, v1alpha5.K3sArgWithNodeFilters{Arg: arg, NodeFilters: nodeFilters})
+	}
+}
+
+// WithServerArgs passes the given arguments to k3s on all server nodes.
+func WithServerArgs(args ...string) ClusterOption {
+	return withK3sArgs(args, allServers)
+}
+
+// WithAgentArgs passes the given arguments to k3s on all agent nodes. See WithAgents.
+func WithAgentArgs(args ...string) ClusterOption {
+	return withK3sArgs(args, allAgents)
+}
+
+// WithAgents adds the given number of agent nodes to the cluster. By default, the cluster consists of a single server.
+func WithAgents(count int) ClusterOption {
+	return func(opts *clusterOptions) {
+		opts.agents = count
+	}
+}
+
+// WithAPIServerArgs passes flags like `v=4` or `audit-log-path=-` to the Kubernetes API server.
+func WithAPIServerArgs(args ...string) ClusterOption {
+	return withK3sArgs(prefixArgs("--kube-apiserver-arg=", args), allServers)
+}
+
+// WithKubeletArgs passes flags like `max-pods=50` to the kubelets of all nodes.
+func WithKubeletArgs(args ...string) ClusterOption {
+	return withK3sArgs(prefixArgs("--kubelet-arg=", args), allServers, allAgents)
+}
+
+// WithAdmissionPlugins enables the given admission plugins in addition to those that are enabled by default.
+func WithAdmissionPlugins(plugins ...string) ClusterOption {
+	return WithAPIServerArgs("enable-admission-plugins=" + strings.Join(plugins, ","))
+}
+
+// WithFeatureGates turns Kubernetes feature gates on or off. The gates are passed to the API server, controller
+// manager and scheduler of all servers as well as to the kubelet and kube-proxy of all nodes.
+func WithFeatureGates(gates map[string]bool) ClusterOption {
+	names := make([]string, 0, len(gates))
+	for name := range gates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var pairs []string
+	for _, name := range names {
+		pairs = append(pairs, fmt.Sprintf("%s=%t", name, gates[name]))
+	}
+	flag := "feature-gates=" + strings.Join(pairs, ",")
+
+	return func(opts *clusterOptions) {
+		for _, component := range []string{"kube-apiserver", "kube-controller-manager", "kube-scheduler"} {
+			WithK3sArgs(fmt.Sprintf("--%s-arg=%s", component, flag), allServers)(opts)
+		}
+		for _, component := range []string{"kubelet", "kube-proxy"} {
+			WithK3sArgs(fmt.Sprintf("--%s-arg=%s", component, flag), allServers, allAgents)(opts)
+		}
+	}
+}
+
+func withK3sArgs(args []string, nodeFilters ...string) ClusterOption {
+	return func(opts *clusterOptions) {
+		for _, arg := range args {
+			WithK3sArgs(arg, nodeFilters...)(opts)
+		}
+	}
+}
+
+func prefixArgs(prefix string, args []string) []string {
+	prefixed := make([]string, 0, len(args))
+	for _, arg := range args {
+		prefixed = append(prefixed, prefix+arg)
+	}
+	return prefixed
+}
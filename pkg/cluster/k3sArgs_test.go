@@ -0,0 +1,58 @@
+package cluster
+
+import (
+	"testing"
+
+	"github.com/k3d-io/k3d/v5/pkg/config/v1alpha5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithK3sArgs(t *testing.T) {
+	// given
+	options := newClusterOptions(
+		WithDisabledComponents(ComponentTraefik),
+		WithAgents(2),
+		WithK3sArgs("--node-label=foo=bar", "agent:0"),
+		WithServerArgs("--debug"),
+		WithAgentArgs("--node-taint=key=value:NoSchedule"),
+		WithAPIServerArgs("v=4", "audit-log-path=-"),
+		WithKubeletArgs("max-pods=50"),
+		WithAdmissionPlugins("NodeRestriction", "PodNodeSelector"),
+	)
+
+	// when
+	config := newSimpleConfig("my-cluster", K3sVersion1_28, 6443, options)
+
+	// then
+	servers := []string{"server:*"}
+	agents := []string{"agent:*"}
+	expected := []v1alpha5.K3sArgWithNodeFilters{
+		{Arg: "--disable=traefik", NodeFilters: servers},
+		{Arg: "--node-label=foo=bar", NodeFilters: []string{"agent:0"}},
+		{Arg: "--debug", NodeFilters: servers},
+		{Arg: "--node-taint=key=value:NoSchedule", NodeFilters: agents},
+		{Arg: "--kube-apiserver-arg=v=4", NodeFilters: servers},
+		{Arg: "--kube-apiserver-arg=audit-log-path=-", NodeFilters: servers},
+		{Arg: "--kubelet-arg=max-pods=50", NodeFilters: []string{"server:*", "agent:*"}},
+		{Arg: "--kube-apiserver-arg=enable-admission-plugins=NodeRestriction,PodNodeSelector", NodeFilters: servers},
+	}
+	assert.Equal(t, expected, config.Options.K3sOptions.ExtraArgs)
+	assert.Equal(t, 2, config.Agents)
+}
+
+func TestWithFeatureGates(t *testing.T) {
+	// when
+	options := newClusterOptions(WithFeatureGates(map[string]bool{"SidecarContainers": true, "InPlacePodVerticalScaling": false}))
+
+	// then
+	flag := "feature-gates=InPlacePodVerticalScaling=false,SidecarContainers=true"
+	allNodes := []string{"server:*", "agent:*"}
+	expected := []v1alpha5.K3sArgWithNodeFilters{
+		{Arg: "--kube-apiserver-arg=" + flag, NodeFilters: []string{"server:*"}},
+		{Arg: "--kube-controller-manager-arg=" + flag, NodeFilters: []string{"server:*"}},
+		{Arg: "--kube-scheduler-arg=" + flag, NodeFilters: []string{"server:*"}},
+		{Arg: "--kubelet-arg=" + flag, NodeFilters: allNodes},
+		{Arg: "--kube-proxy-arg=" + flag, NodeFilters: allNodes},
+	}
+	assert.Equal(t, expected, options.extraArgs)
+}
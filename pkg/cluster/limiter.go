@@ -0,0 +1,117 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// MaxClustersEnv names the environment variable that limits the number of clusters which exist at the same time within
+// a test process. See SetMaxConcurrentClusters.
+const MaxClustersEnv = "TESTCLUSTERS_MAX_CLUSTERS"
+
+var clusterSlots = newClusterLimiter(maxClustersFromEnv())
+
+// SetMaxConcurrentClusters limits the number of clusters which exist at the same time within this process. Creating
+// another cluster blocks until an existing cluster was terminated. A limit of zero or less removes the limit. The
+// default limit is read from the environment variable TESTCLUSTERS_MAX_CLUSTERS.
+//
+// Clusters that exist while the limit is changed still count against the limit that was active during their creation.
+func SetMaxConcurrentClusters(limit int) {
+	clusterSlots.setLimit(limit)
+}
+
+func maxClustersFromEnv() int {
+	limit, err := strconv.Atoi(os.Getenv(MaxClustersEnv))
+	if err != nil {
+		return 0
+	}
+	return limit
+}
+
+// clusterLimiter hands out a limited number of slots for clusters.
+type clusterLimiter struct {
+	mu sync.Mutex
+	// slots contains one element per cluster in existence. A nil channel means that there is no limit.
+	slots chan struct{}
+	// holders counts the slots per test name.
+	holders map[string]int
+}
+
+func newClusterLimiter(limit int) *clusterLimiter {
+	limiter := &clusterLimiter{holders: map[string]int{}}
+	limiter.setLimit(limit)
+	return limiter
+}
+
+func (cl *clusterLimiter) setLimit(limit int) {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+
+	cl.slots = nil
+	if limit > 0 {
+		cl.slots = make(chan struct{}, limit)
+	}
+}
+
+// acquire blocks until a slot is available or the context is done. The slot is held by the test with the given name,
+// which is empty for clusters that were not created for a test. The returned function gives the slot back; it may be
+// called more than once.
+func (cl *clusterLimiter) acquire(ctx context.Context, testName string) (release func(), err error) {
+	cl.mu.Lock()
+	slots := cl.slots
+	cl.mu.Unlock()
+
+	if slots == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, fmt.Errorf("waited too long for one of %d cluster slots, %d of them held by %s: %w",
+			cap(slots), cl.heldBy(testName), describeHolder(testName), ctx.Err())
+	}
+
+	cl.mu.Lock()
+	cl.holders[testName]++
+	cl.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			cl.mu.Lock()
+			cl.holders[testName]--
+			if cl.holders[testName] == 0 {
+				delete(cl.holders, testName)
+			}
+			cl.mu.Unlock()
+			<-slots
+		})
+	}, nil
+}
+
+// heldBy returns the number of slots that are held by the test and its parent tests. A test waiting for slots that only
+// its parent tests or itself could free will never get one.
+func (cl *clusterLimiter) heldBy(testName string) int {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+
+	held := 0
+	for holder, count := range cl.holders {
+		if holder == testName || (holder != "" && strings.HasPrefix(testName, holder+"/")) {
+			held += count
+		}
+	}
+	return held
+}
+
+func describeHolder(testName string) string {
+	if testName == "" {
+		return "clusters without test"
+	}
+	return fmt.Sprintf("test %s and its parent tests", testName)
+}
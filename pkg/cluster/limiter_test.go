@@ -0,0 +1,80 @@
+package cluster
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_clusterLimiter_acquire(t *testing.T) {
+	t.Run("should block when all slots are taken", func(t *testing.T) {
+		// given
+		sut := newClusterLimiter(1)
+		release, err := sut.acquire(context.Background(), "")
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		// when
+		_, err = sut.acquire(ctx, "")
+
+		// then
+		require.Error(t, err)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.ErrorContains(t, err, "waited too long for one of 1 cluster slots")
+		release()
+	})
+	t.Run("should name the slots held by the test and its parents", func(t *testing.T) {
+		// given
+		sut := newClusterLimiter(3)
+		_, err := sut.acquire(context.Background(), "TestParent")
+		require.NoError(t, err)
+		_, err = sut.acquire(context.Background(), "TestParent/sub")
+		require.NoError(t, err)
+		_, err = sut.acquire(context.Background(), "TestParentOther")
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		// when
+		_, err = sut.acquire(ctx, "TestParent/sub")
+
+		// then
+		assert.EqualError(t, err, "waited too long for one of 3 cluster slots, 2 of them held by test TestParent/sub and its parent tests: context deadline exceeded")
+	})
+	t.Run("should hand out released slot", func(t *testing.T) {
+		// given
+		sut := newClusterLimiter(1)
+		release, err := sut.acquire(context.Background(), "")
+		require.NoError(t, err)
+
+		// when
+		release()
+		release()
+		secondRelease, err := sut.acquire(context.Background(), "")
+
+		// then
+		require.NoError(t, err)
+		assert.Len(t, sut.slots, 1)
+		secondRelease()
+		assert.Len(t, sut.slots, 0)
+		assert.Empty(t, sut.holders)
+	})
+	t.Run("should not block without limit", func(t *testing.T) {
+		// given
+		sut := newClusterLimiter(0)
+
+		// when
+		for i := 0; i < 10; i++ {
+			_, err := sut.acquire(context.Background(), "")
+
+			// then
+			require.NoError(t, err)
+		}
+	})
+}
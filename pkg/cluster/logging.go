@@ -0,0 +1,141 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+
+	l "github.com/k3d-io/k3d/v5/pkg/logger"
+	"github.com/sirupsen/logrus"
+)
+
+// testLog is the part of testing.TB that is needed to write log output into a test's log.
+type testLog interface {
+	Log(args ...any)
+}
+
+// testLogWriter writes every log record as a separate entry into the test log, so that the output is attributed to the
+// test that produced it, even if tests run in parallel.
+type testLogWriter struct {
+	t testLog
+}
+
+func (w *testLogWriter) Write(p []byte) (int, error) {
+	w.t.Log(strings.TrimSuffix(string(p), "\n"))
+	return len(p), nil
+}
+
+// newTestLogger returns a logger which writes records of the given level or above into the test log.
+func newTestLogger(t testLog, level slog.Leveler) *slog.Logger {
+	return slog.New(slog.NewTextHandler(&testLogWriter{t: t}, &slog.HandlerOptions{Level: level}))
+}
+
+var (
+	k3dLogCaptureOnce sync.Once
+	k3dLogTargetsMu   sync.RWMutex
+	k3dLogTargets     = map[string]*k3dLogTarget{}
+)
+
+// k3dLogTarget is the logger of a cluster that captures k3d's output.
+type k3dLogTarget struct {
+	logger *slog.Logger
+	// captures counts the operations of the cluster that currently capture k3d's output.
+	captures int
+}
+
+// captureK3dLogs forwards the log output of k3d's global logger that concerns the given cluster into the given logger
+// until the returned function is called. Output that cannot be attributed to a capturing cluster goes to the default
+// logger.
+func captureK3dLogs(clusterName string, logger *slog.Logger) (release func()) {
+	k3dLogCaptureOnce.Do(func() {
+		l.Log().SetOutput(io.Discard)
+		l.Log().SetLevel(logrus.DebugLevel)
+		l.Log().AddHook(&k3dLogHook{})
+	})
+
+	k3dLogTargetsMu.Lock()
+	target, ok := k3dLogTargets[clusterName]
+	if !ok {
+		target = &k3dLogTarget{logger: logger}
+		k3dLogTargets[clusterName] = target
+	}
+	target.captures++
+	k3dLogTargetsMu.Unlock()
+
+	return func() {
+		k3dLogTargetsMu.Lock()
+		defer k3dLogTargetsMu.Unlock()
+		target.captures--
+		if target.captures <= 0 {
+			delete(k3dLogTargets, clusterName)
+		}
+	}
+}
+
+// k3dLogHook forwards logrus entries of k3d to the logger of the cluster they concern.
+type k3dLogHook struct{}
+
+func (h *k3dLogHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *k3dLogHook) Fire(entry *logrus.Entry) error {
+	level := logrusToSlogLevel(entry.Level)
+	message := strings.TrimSuffix(entry.Message, "\n")
+	attrs := make([]slog.Attr, 0, len(entry.Data)+1)
+	attrs = append(attrs, slog.String("source", "k3d"))
+	texts := []string{message}
+	for key, value := range entry.Data {
+		attrs = append(attrs, slog.Any(key, value))
+		texts = append(texts, fmt.Sprint(value))
+	}
+
+	ctx := entry.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	k3dLogTargetsMu.RLock()
+	logger := k3dLogTargetFor(texts)
+	k3dLogTargetsMu.RUnlock()
+
+	logger.LogAttrs(ctx, level, message, attrs...)
+	return nil
+}
+
+// k3dLogTargetFor returns the logger of the cluster whose name occurs in one of the texts of a log entry. k3d names
+// all containers, networks and volumes of a cluster after the cluster. If several names occur, the longest one wins
+// because it is the most specific. The caller must hold k3dLogTargetsMu.
+func k3dLogTargetFor(texts []string) *slog.Logger {
+	logger := slog.Default()
+	matched := ""
+	for clusterName, target := range k3dLogTargets {
+		if len(clusterName) <= len(matched) {
+			continue
+		}
+		for _, text := range texts {
+			if strings.Contains(text, clusterName) {
+				logger = target.logger
+				matched = clusterName
+				break
+			}
+		}
+	}
+	return logger
+}
+
+func logrusToSlogLevel(level logrus.Level) slog.Level {
+	switch level {
+	case logrus.PanicLevel, logrus.FatalLevel, logrus.ErrorLevel:
+		return slog.LevelError
+	case logrus.WarnLevel:
+		return slog.LevelWarn
+	case logrus.InfoLevel:
+		return slog.LevelInfo
+	default:
+		return slog.LevelDebug
+	}
+}
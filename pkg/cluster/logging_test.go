@@ -0,0 +1,104 @@
+package cluster
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"testing"
+
+	l "github.com/k3d-io/k3d/v5/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+type recordingTestLog struct {
+	entries []string
+}
+
+func (r *recordingTestLog) Log(args ...any) {
+	r.entries = append(r.entries, fmt.Sprint(args...))
+}
+
+func Test_newTestLogger(t *testing.T) {
+	// given
+	testLog := &recordingTestLog{}
+	sut := newTestLogger(testLog, slog.LevelInfo)
+
+	// when
+	sut.Debug("hidden")
+	sut.Info("first", "cluster", "hello-world-1234abcd")
+	sut.Warn("second")
+
+	// then
+	require.Len(t, testLog.entries, 2)
+	assert.Contains(t, testLog.entries[0], `level=INFO msg=first cluster=hello-world-1234abcd`)
+	assert.NotContains(t, testLog.entries[0], "\n")
+	assert.Contains(t, testLog.entries[1], `level=WARN msg=second`)
+}
+
+func Test_captureK3dLogs(t *testing.T) {
+	t.Run("should forward entries of the cluster", func(t *testing.T) {
+		// given
+		buf := &bytes.Buffer{}
+		logger := slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+		// when
+		release := captureK3dLogs("hello-world-1234abcd", logger)
+		l.Log().WithField("node", "k3d-hello-world-1234abcd-server-0").Info("Starting node")
+		l.Log().Debug("Waiting for cluster 'hello-world-1234abcd'")
+		release()
+		l.Log().Info("Stopping hello-world-1234abcd")
+
+		// then
+		assert.Contains(t, buf.String(), `level=INFO msg="Starting node" source=k3d node=k3d-hello-world-1234abcd-server-0`)
+		assert.Contains(t, buf.String(), `level=DEBUG msg="Waiting for cluster 'hello-world-1234abcd'" source=k3d`)
+		assert.NotContains(t, buf.String(), "Stopping")
+	})
+	t.Run("should separate clusters and send unattributed entries to the default logger", func(t *testing.T) {
+		// given
+		first, second, fallback := &bytes.Buffer{}, &bytes.Buffer{}, &bytes.Buffer{}
+		newLogger := func(buf *bytes.Buffer) *slog.Logger {
+			return slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+		}
+		defaultLogger := slog.Default()
+		slog.SetDefault(newLogger(fallback))
+		defer slog.SetDefault(defaultLogger)
+
+		releaseFirst := captureK3dLogs("first-1234abcd", newLogger(first))
+		defer releaseFirst()
+		releaseSecond := captureK3dLogs("second-1234abcd", newLogger(second))
+		defer releaseSecond()
+
+		// when
+		l.Log().Info("Creating node 'k3d-first-1234abcd-server-0'")
+		l.Log().Info("Creating node 'k3d-second-1234abcd-server-0'")
+		l.Log().Info("Pulling image")
+
+		// then
+		assert.Contains(t, first.String(), "k3d-first-1234abcd-server-0")
+		assert.NotContains(t, first.String(), "second")
+		assert.Contains(t, second.String(), "k3d-second-1234abcd-server-0")
+		assert.NotContains(t, second.String(), "first")
+		assert.Contains(t, fallback.String(), "Pulling image")
+		assert.NotContains(t, first.String()+second.String(), "Pulling image")
+	})
+}
+
+func TestK3dCluster_CommandExecutor(t *testing.T) {
+	// given
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+	cluster := &K3dCluster{
+		kubeConfig:   &api.Config{},
+		clientConfig: &rest.Config{Host: "https://127.0.0.1:6443"},
+		logger:       logger,
+	}
+
+	// when
+	executor, err := cluster.CommandExecutor()
+
+	// then
+	require.NoError(t, err)
+	assert.Same(t, logger, executor.logger)
+}
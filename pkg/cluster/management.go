@@ -0,0 +1,142 @@
+package cluster
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/k3d-io/k3d/v5/pkg/client"
+	"github.com/k3d-io/k3d/v5/pkg/runtimes"
+	runtimeTypes "github.com/k3d-io/k3d/v5/pkg/runtimes/types"
+	k3dTypes "github.com/k3d-io/k3d/v5/pkg/types"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+// ErrNotATestCluster is returned when a cluster exists but was not created by testclusters-go.
+var ErrNotATestCluster = errors.New("cluster was not created by testclusters-go")
+
+// GetCluster returns information about the cluster with the given name in k3d's selected container runtime.
+// ErrNotATestCluster is returned for clusters that were not created by testclusters-go.
+func GetCluster(ctx context.Context, name string) (ClusterInfo, error) {
+	_, info, err := getTestCluster(ctx, runtimes.SelectedRuntime, name)
+	return info, err
+}
+
+// DeleteCluster deletes the cluster with the given name if it was created by testclusters-go.
+func DeleteCluster(ctx context.Context, name string) error {
+	cluster, _, err := getTestCluster(ctx, runtimes.SelectedRuntime, name)
+	if err != nil {
+		return err
+	}
+
+	err = client.ClusterDelete(ctx, runtimes.SelectedRuntime, cluster, k3dTypes.ClusterDeleteOpts{})
+	if err != nil {
+		return fmt.Errorf("could not delete cluster %s: %w", name, err)
+	}
+	return nil
+}
+
+// KubeConfig returns a kubeconfig for the cluster with the given name if it was created by testclusters-go.
+func KubeConfig(ctx context.Context, name string) (*api.Config, error) {
+	cluster, _, err := getTestCluster(ctx, runtimes.SelectedRuntime, name)
+	if err != nil {
+		return nil, err
+	}
+
+	kubeConfig, err := client.KubeconfigGet(ctx, runtimes.SelectedRuntime, cluster)
+	if err != nil {
+		return nil, fmt.Errorf("could not get kubeconfig of cluster %s: %w", name, err)
+	}
+	return kubeConfig, nil
+}
+
+// WriteServerLogs writes the logs of all k3s servers of the cluster with the given name into the given writer. With
+// follow, WriteServerLogs blocks until the context is done and writes new log lines of all servers as they appear; each
+// line is then prefixed with `[node]`.
+func WriteServerLogs(ctx context.Context, name string, w io.Writer, follow bool) error {
+	cluster, _, err := getTestCluster(ctx, runtimes.SelectedRuntime, name)
+	if err != nil {
+		return err
+	}
+
+	var servers []*k3dTypes.Node
+	for _, node := range cluster.Nodes {
+		if node.Role == k3dTypes.ServerRole {
+			servers = append(servers, node)
+		}
+	}
+	if follow {
+		return followNodeLogs(ctx, runtimes.SelectedRuntime, servers, w)
+	}
+
+	var errs []error
+	for _, node := range servers {
+		errs = append(errs, writeNodeLogs(ctx, runtimes.SelectedRuntime, node, w, false))
+	}
+	return errors.Join(errs...)
+}
+
+func getTestCluster(ctx context.Context, containerRuntime runtimes.Runtime, name string) (*k3dTypes.Cluster, ClusterInfo, error) {
+	cluster, err := client.ClusterGet(ctx, containerRuntime, &k3dTypes.Cluster{Name: name})
+	if err != nil {
+		return nil, ClusterInfo{}, fmt.Errorf("could not get cluster %s: %w", name, err)
+	}
+
+	info, ok := clusterInfoFromNodes(cluster.Name, cluster.Nodes)
+	if !ok {
+		return nil, ClusterInfo{}, fmt.Errorf("could not use cluster %s: %w", name, ErrNotATestCluster)
+	}
+	return cluster, info, nil
+}
+
+func writeNodeLogs(ctx context.Context, containerRuntime runtimes.Runtime, node *k3dTypes.Node, w io.Writer, follow bool) error {
+	reader, err := containerRuntime.GetNodeLogs(ctx, node, time.Time{}, &runtimeTypes.NodeLogsOpts{Follow: follow})
+	if err != nil {
+		return fmt.Errorf("could not get logs of node %s: %w", node.Name, err)
+	}
+	defer reader.Close()
+
+	// container logs without TTY are multiplexed into stdout and stderr frames
+	_, err = stdcopy.StdCopy(w, w, reader)
+	if err != nil && ctx.Err() == nil {
+		return fmt.Errorf("could not read logs of node %s: %w", node.Name, err)
+	}
+	return nil
+}
+
+// followNodeLogs follows the logs of all given nodes at once and serializes their lines into the given writer. Each line
+// is prefixed with `[node]`.
+func followNodeLogs(ctx context.Context, containerRuntime runtimes.Runtime, nodes []*k3dTypes.Node, w io.Writer) error {
+	var outMu sync.Mutex
+	var wg sync.WaitGroup
+	errs := make([]error, len(nodes))
+	for i, node := range nodes {
+		wg.Add(1)
+		go func(i int, node *k3dTypes.Node) {
+			defer wg.Done()
+
+			reader, writer := io.Pipe()
+			defer reader.Close()
+			go func() {
+				_ = writer.CloseWithError(writeNodeLogs(ctx, containerRuntime, node, writer, true))
+			}()
+
+			prefix := fmt.Sprintf("[%s] ", node.Name)
+			scanner := bufio.NewScanner(reader)
+			for scanner.Scan() {
+				outMu.Lock()
+				_, _ = io.WriteString(w, prefix+scanner.Text()+"\n")
+				outMu.Unlock()
+			}
+			errs[i] = scanner.Err()
+		}(i, node)
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
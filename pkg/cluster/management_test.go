@@ -0,0 +1,66 @@
+package cluster
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/k3d-io/k3d/v5/pkg/runtimes"
+	runtimeTypes "github.com/k3d-io/k3d/v5/pkg/runtimes/types"
+	k3dTypes "github.com/k3d-io/k3d/v5/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeLogRuntime streams the given log lines of each node and keeps the stream open until the context is done, like a
+// followed container log. Other runtime methods must not be called.
+type fakeLogRuntime struct {
+	runtimes.Runtime
+	logs map[string][]string
+}
+
+func (f *fakeLogRuntime) GetNodeLogs(ctx context.Context, node *k3dTypes.Node, _ time.Time, _ *runtimeTypes.NodeLogsOpts) (io.ReadCloser, error) {
+	reader, writer := io.Pipe()
+	go func() {
+		stdout := stdcopy.NewStdWriter(writer, stdcopy.Stdout)
+		for _, line := range f.logs[node.Name] {
+			_, _ = io.WriteString(stdout, line+"\n")
+		}
+		<-ctx.Done()
+		_ = writer.Close()
+	}()
+	return reader, nil
+}
+
+func Test_followNodeLogs(t *testing.T) {
+	// given
+	containerRuntime := &fakeLogRuntime{logs: map[string][]string{
+		"k3d-hello-server-0": {"first server starting", "first server ready"},
+		"k3d-hello-server-1": {"second server starting"},
+	}}
+	nodes := []*k3dTypes.Node{{Name: "k3d-hello-server-0"}, {Name: "k3d-hello-server-1"}}
+	out := &syncBuffer{}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// when
+	errCh := make(chan error)
+	go func() {
+		errCh <- followNodeLogs(ctx, containerRuntime, nodes, out)
+	}()
+
+	// then
+	assert.Eventually(t, func() bool {
+		return strings.Count(out.String(), "\n") == 3
+	}, 5*time.Second, 10*time.Millisecond)
+	cancel()
+	require.NoError(t, <-errCh)
+
+	actual := out.String()
+	assert.Contains(t, actual, "[k3d-hello-server-0] first server starting\n")
+	assert.Contains(t, actual, "[k3d-hello-server-0] first server ready\n")
+	assert.Contains(t, actual, "[k3d-hello-server-1] second server starting\n")
+}
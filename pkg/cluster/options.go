@@ -0,0 +1,108 @@
+package cluster
+
+import (
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/k3d-io/k3d/v5/pkg/config/v1alpha5"
+	"github.com/k3d-io/k3d/v5/pkg/runtimes"
+)
+
+// KeepOnFailureEnv names the environment variable that keeps clusters of failed tests alive when set to a true value
+// like `1` or `true`. See WithKeepOnFailure.
+const KeepOnFailureEnv = "TESTCLUSTERS_KEEP_ON_FAILURE"
+
+// ClusterOption customizes a cluster created by NewK3dCluster or CreateK3dCluster.
+type ClusterOption func(opts *clusterOptions)
+
+type clusterOptions struct {
+	containerRuntime runtimes.Runtime
+	keepOnFailure    bool
+	// t receives the log output if no logger was configured.
+	t        testLog
+	testName string
+	logger   *slog.Logger
+	logLevel slog.Level
+
+	readinessGates   []ReadinessGate
+	readinessTimeout time.Duration
+
+	kubernetesVersion string
+	agents            int
+	extraArgs         []v1alpha5.K3sArgWithNodeFilters
+
+	disabledComponents []K3sComponent
+	startupManifests   [][]byte
+}
+
+func newClusterOptions(opts ...ClusterOption) *clusterOptions {
+	keepOnFailure, _ := strconv.ParseBool(os.Getenv(KeepOnFailureEnv))
+
+	options := &clusterOptions{
+		containerRuntime:  runtimes.SelectedRuntime,
+		keepOnFailure:     keepOnFailure,
+		readinessTimeout:  defaultReadinessTimeout,
+		kubernetesVersion: DefaultKubernetesVersion,
+	}
+	if version := os.Getenv(KubernetesVersionEnv); version != "" {
+		options.kubernetesVersion = version
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	if options.logger == nil {
+		options.logger = slog.Default()
+		if options.t != nil {
+			options.logger = newTestLogger(options.t, options.logLevel)
+		}
+	}
+	return options
+}
+
+// withTestLog writes the log output of the cluster into the test log unless another logger is configured.
+func withTestLog(t testLog) ClusterOption {
+	return func(opts *clusterOptions) {
+		opts.t = t
+	}
+}
+
+// withTestName labels the cluster with the name of the test that created it.
+func withTestName(testName string) ClusterOption {
+	return func(opts *clusterOptions) {
+		opts.testName = testName
+	}
+}
+
+// WithContainerRuntime creates the cluster in the given container runtime instead of k3d's globally selected runtime.
+func WithContainerRuntime(runtime runtimes.Runtime) ClusterOption {
+	return func(opts *clusterOptions) {
+		opts.containerRuntime = runtime
+	}
+}
+
+// WithKeepOnFailure skips the termination of the cluster if the test failed. Instead, the cluster's kubeconfig is
+// written to a file and instructions for inspecting and deleting the cluster are logged.
+func WithKeepOnFailure() ClusterOption {
+	return func(opts *clusterOptions) {
+		opts.keepOnFailure = true
+	}
+}
+
+// WithLogger sends the log output of the cluster and of k3d into the given logger. By default, clusters created by
+// NewK3dCluster log into the test log, clusters created by CreateK3dCluster log into slog's default logger.
+func WithLogger(logger *slog.Logger) ClusterOption {
+	return func(opts *clusterOptions) {
+		opts.logger = logger
+	}
+}
+
+// WithLogLevel sets the minimum level of records written into the test log. The default level is slog.LevelInfo. The
+// level is ignored if a logger is configured with WithLogger.
+func WithLogLevel(level slog.Level) ClusterOption {
+	return func(opts *clusterOptions) {
+		opts.logLevel = level
+	}
+}
@@ -0,0 +1,31 @@
+package cluster
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_newClusterOptions(t *testing.T) {
+	t.Run("should not keep cluster by default", func(t *testing.T) {
+		t.Setenv(KeepOnFailureEnv, "")
+
+		actual := newClusterOptions()
+
+		assert.False(t, actual.keepOnFailure)
+	})
+	t.Run("should keep cluster when enabled by env var", func(t *testing.T) {
+		t.Setenv(KeepOnFailureEnv, "1")
+
+		actual := newClusterOptions()
+
+		assert.True(t, actual.keepOnFailure)
+	})
+	t.Run("should keep cluster when enabled by option", func(t *testing.T) {
+		t.Setenv(KeepOnFailureEnv, "false")
+
+		actual := newClusterOptions(WithKeepOnFailure())
+
+		assert.True(t, actual.keepOnFailure)
+	})
+}
@@ -0,0 +1,160 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/k3d-io/k3d/v5/pkg/client"
+	"github.com/k3d-io/k3d/v5/pkg/runtimes"
+	k3dTypes "github.com/k3d-io/k3d/v5/pkg/types"
+)
+
+// Container labels which identify the process and test that created a cluster.
+const (
+	LabelOwnerPID  = "testclusters.io/owner-pid"
+	LabelOwnerHost = "testclusters.io/owner-host"
+	LabelCreated   = "testclusters.io/created"
+	LabelTestName  = "testclusters.io/test"
+)
+
+// PruneOrphansEnv names the environment variable that enables pruning orphaned clusters before the first cluster of a
+// process is created. Its value is the minimum age of the pruned clusters, f. e. `0s` or `1h`. See PruneOrphans.
+const PruneOrphansEnv = "TESTCLUSTERS_PRUNE_ORPHANS"
+
+var pruneOnStartupOnce sync.Once
+
+// ClusterInfo describes a cluster that was created by testclusters-go and still exists in the container runtime.
+type ClusterInfo struct {
+	Name      string
+	OwnerPID  int
+	OwnerHost string
+	Created   time.Time
+	TestName  string
+}
+
+// IsOrphaned returns true if the process that created the cluster runs on this host and has exited.
+func (ci ClusterInfo) IsOrphaned() bool {
+	if ci.OwnerPID <= 0 || ci.OwnerHost != currentHost() || ci.OwnerPID == os.Getpid() {
+		return false
+	}
+	return !processExists(ci.OwnerPID)
+}
+
+// ListClusters returns all clusters in k3d's selected container runtime that were created by testclusters-go.
+func ListClusters(ctx context.Context) ([]ClusterInfo, error) {
+	return listClusters(ctx, runtimes.SelectedRuntime)
+}
+
+func listClusters(ctx context.Context, containerRuntime runtimes.Runtime) ([]ClusterInfo, error) {
+	clusters, err := client.ClusterList(ctx, containerRuntime)
+	if err != nil {
+		return nil, fmt.Errorf("could not list clusters: %w", err)
+	}
+
+	var result []ClusterInfo
+	for _, cluster := range clusters {
+		info, ok := clusterInfoFromNodes(cluster.Name, cluster.Nodes)
+		if ok {
+			result = append(result, info)
+		}
+	}
+	return result, nil
+}
+
+func clusterInfoFromNodes(clusterName string, nodes []*k3dTypes.Node) (ClusterInfo, bool) {
+	for _, node := range nodes {
+		labels := node.RuntimeLabels
+		pid, err := strconv.Atoi(labels[LabelOwnerPID])
+		if err != nil {
+			continue
+		}
+		created, _ := time.Parse(time.RFC3339, labels[LabelCreated])
+
+		return ClusterInfo{
+			Name:      clusterName,
+			OwnerPID:  pid,
+			OwnerHost: labels[LabelOwnerHost],
+			Created:   created,
+			TestName:  labels[LabelTestName],
+		}, true
+	}
+	return ClusterInfo{}, false
+}
+
+// PruneOrphans deletes all clusters that are older than the given duration and whose creating process on this host has
+// exited, f. e. because the test binary was killed before it could clean up. It returns the names of the deleted
+// clusters.
+func PruneOrphans(ctx context.Context, olderThan time.Duration) ([]string, error) {
+	return pruneOrphans(ctx, runtimes.SelectedRuntime, olderThan)
+}
+
+func pruneOrphans(ctx context.Context, containerRuntime runtimes.Runtime, olderThan time.Duration) ([]string, error) {
+	clusters, err := listClusters(ctx, containerRuntime)
+	if err != nil {
+		return nil, err
+	}
+
+	var deleted []string
+	var errs []error
+	for _, cluster := range clusters {
+		if !cluster.IsOrphaned() || time.Since(cluster.Created) < olderThan {
+			continue
+		}
+
+		err = client.ClusterDelete(ctx, containerRuntime, &k3dTypes.Cluster{Name: cluster.Name}, k3dTypes.ClusterDeleteOpts{})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("could not delete orphaned cluster %s: %w", cluster.Name, err))
+			continue
+		}
+		deleted = append(deleted, cluster.Name)
+	}
+
+	return deleted, errors.Join(errs...)
+}
+
+// pruneOrphansOnStartup prunes orphaned clusters once per process if enabled by PruneOrphansEnv.
+func pruneOrphansOnStartup(ctx context.Context, containerRuntime runtimes.Runtime, logger *slog.Logger) {
+	pruneOnStartupOnce.Do(func() {
+		value := os.Getenv(PruneOrphansEnv)
+		if value == "" {
+			return
+		}
+		olderThan, err := time.ParseDuration(value)
+		if err != nil {
+			logger.Warn(fmt.Sprintf("testcluster-go: ignoring invalid value of %s: %s", PruneOrphansEnv, err.Error()))
+			return
+		}
+
+		deleted, err := pruneOrphans(ctx, containerRuntime, olderThan)
+		for _, name := range deleted {
+			logger.Info("testcluster-go: Deleted orphaned cluster", "orphan", name)
+		}
+		if err != nil {
+			logger.Warn(fmt.Sprintf("testcluster-go: pruning orphaned clusters failed: %s", err.Error()))
+		}
+	})
+}
+
+// ownerLabels identify the current process as owner of a new cluster.
+func ownerLabels(testName string) map[string]string {
+	labels := map[string]string{
+		LabelOwnerPID:  strconv.Itoa(os.Getpid()),
+		LabelOwnerHost: currentHost(),
+		LabelCreated:   time.Now().UTC().Format(time.RFC3339),
+	}
+	if testName != "" {
+		labels[LabelTestName] = testName
+	}
+	return labels
+}
+
+func currentHost() string {
+	host, _ := os.Hostname()
+	return host
+}
@@ -0,0 +1,80 @@
+package cluster
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/k3d-io/k3d/v5/pkg/runtimes"
+	k3dTypes "github.com/k3d-io/k3d/v5/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeNodeRuntime returns a fixed set of nodes. Other runtime methods must not be called.
+type fakeNodeRuntime struct {
+	runtimes.Runtime
+	nodes []*k3dTypes.Node
+}
+
+func (f *fakeNodeRuntime) GetNodesByLabel(_ context.Context, _ map[string]string) ([]*k3dTypes.Node, error) {
+	return f.nodes, nil
+}
+
+func fakeNode(name, clusterName string, labels map[string]string) *k3dTypes.Node {
+	runtimeLabels := map[string]string{k3dTypes.LabelClusterName: clusterName}
+	for key, value := range labels {
+		runtimeLabels[key] = value
+	}
+	return &k3dTypes.Node{Name: name, Role: k3dTypes.ServerRole, RuntimeLabels: runtimeLabels}
+}
+
+func exitedPID(t *testing.T) int {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+	require.NoError(t, cmd.Run())
+	return cmd.Process.Pid
+}
+
+func Test_listClusters(t *testing.T) {
+	// given
+	labels := ownerLabels("TestSomething/sub")
+	containerRuntime := &fakeNodeRuntime{nodes: []*k3dTypes.Node{
+		fakeNode("k3d-hello-world-1234abcd-server-0", "hello-world-1234abcd", labels),
+		fakeNode("k3d-foreign-server-0", "foreign", nil),
+	}}
+
+	// when
+	actual, err := listClusters(context.Background(), containerRuntime)
+
+	// then
+	require.NoError(t, err)
+	require.Len(t, actual, 1)
+	assert.Equal(t, "hello-world-1234abcd", actual[0].Name)
+	assert.Equal(t, os.Getpid(), actual[0].OwnerPID)
+	assert.Equal(t, currentHost(), actual[0].OwnerHost)
+	assert.Equal(t, "TestSomething/sub", actual[0].TestName)
+	assert.WithinDuration(t, time.Now(), actual[0].Created, time.Minute)
+}
+
+func TestClusterInfo_IsOrphaned(t *testing.T) {
+	tests := []struct {
+		name string
+		info ClusterInfo
+		want bool
+	}{
+		{"own process", ClusterInfo{OwnerPID: os.Getpid(), OwnerHost: currentHost()}, false},
+		{"running process", ClusterInfo{OwnerPID: os.Getppid(), OwnerHost: currentHost()}, false},
+		{"exited process", ClusterInfo{OwnerPID: exitedPID(t), OwnerHost: currentHost()}, true},
+		{"other host", ClusterInfo{OwnerPID: exitedPID(t), OwnerHost: "elsewhere"}, false},
+		{"unknown owner", ClusterInfo{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.info.IsOrphaned(), "PID %s", strconv.Itoa(tt.info.OwnerPID))
+		})
+	}
+}
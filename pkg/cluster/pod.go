@@ -28,8 +28,10 @@ func (ps *PodSelector) Events(ctx context.Context, fieldSelectors ...string) (*c
 	})
 }
 
-func (ps *PodSelector) Logs(ctx context.Context) ([]byte, error) {
-	podLogOpts := &corev1.PodLogOptions{}
+// Logs returns the current logs of the pod. Options allow to select a container, a previous container instance, or to
+// limit the returned lines.
+func (ps *PodSelector) Logs(ctx context.Context, opts ...LogOption) ([]byte, error) {
+	podLogOpts := newPodLogOptions(opts...)
 	logReq := ps.podClient.GetLogs(ps.name, podLogOpts)
 	result := logReq.Do(ctx)
 	if result.Error() != nil {
@@ -0,0 +1,197 @@
+package cluster
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// Delays between two pod lists of StreamLogs. The delay grows while watches end quickly and is reset once a watch
+// lasted longer than the maximum delay.
+var (
+	relistMinDelay = 100 * time.Millisecond
+	relistMaxDelay = 5 * time.Second
+)
+
+// Logs returns the current logs of all containers of all listed pods. The result is keyed by `pod/container`. A
+// container selected by WithLogContainer is ignored because every container of every pod is queried.
+func (pl *PodList) Logs(ctx context.Context, opts ...LogOption) (map[string][]byte, error) {
+	list, err := pl.Raw(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not list pods for listOptions %s: %w", pl.listOptions.String(), err)
+	}
+
+	result := map[string][]byte{}
+	for _, pod := range list.Items {
+		for _, container := range pod.Spec.Containers {
+			podLogOpts := newPodLogOptions(opts...)
+			podLogOpts.Container = container.Name
+
+			raw, err := pl.podClient.GetLogs(pod.Name, podLogOpts).Do(ctx).Raw()
+			if err != nil {
+				return nil, fmt.Errorf("could not get logs of container %s in pod %s: %w", container.Name, pod.Name, err)
+			}
+			result[podContainerKey(pod.Name, container.Name)] = raw
+		}
+	}
+
+	return result, nil
+}
+
+// StreamLogs follows the logs of all containers of all listed pods and writes them line by line into the given writer.
+// Each line is prefixed with `[pod/container]`. Pods that match the list options later on are picked up as soon as
+// their containers start. StreamLogs blocks until the context is done; a cancelled context is not reported as error.
+func (pl *PodList) StreamLogs(ctx context.Context, w io.Writer, opts ...LogOption) error {
+	mux := &logMultiplexer{
+		podList: pl,
+		out:     w,
+		opts:    opts,
+		started: map[string]bool{},
+	}
+	defer mux.wg.Wait()
+
+	backoff := newRelistBackoff()
+	for ctx.Err() == nil {
+		watchStart := time.Now()
+		err := mux.watchPods(ctx)
+		if err != nil && ctx.Err() == nil {
+			return err
+		}
+
+		if time.Since(watchStart) > relistMaxDelay {
+			backoff = newRelistBackoff()
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(backoff.Step()):
+		}
+	}
+
+	return nil
+}
+
+func newRelistBackoff() wait.Backoff {
+	return wait.Backoff{Duration: relistMinDelay, Factor: 2, Cap: relistMaxDelay, Steps: math.MaxInt32}
+}
+
+func podContainerKey(pod, container string) string {
+	return pod + "/" + container
+}
+
+// logMultiplexer starts one log stream per container instance and serializes their lines into a single writer.
+type logMultiplexer struct {
+	podList *PodList
+	opts    []LogOption
+
+	outMu sync.Mutex
+	out   io.Writer
+
+	// started contains the IDs of container instances whose logs are already followed.
+	started map[string]bool
+	wg      sync.WaitGroup
+}
+
+func (m *logMultiplexer) watchPods(ctx context.Context) error {
+	list, err := m.podList.Raw(ctx)
+	if err != nil {
+		return fmt.Errorf("could not list pods for listOptions %s: %w", m.podList.listOptions.String(), err)
+	}
+	for i := range list.Items {
+		m.followPod(ctx, &list.Items[i])
+	}
+
+	watchOptions := m.podList.listOptions
+	watchOptions.ResourceVersion = list.ResourceVersion
+	watcher, err := m.podList.podClient.Watch(ctx, watchOptions)
+	if err != nil {
+		return fmt.Errorf("could not watch pods for listOptions %s: %w", m.podList.listOptions.String(), err)
+	}
+	defer watcher.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
+				// the API server closes watches from time to time; the caller re-establishes it
+				return nil
+			}
+			if event.Type == watch.Error {
+				err := apierrors.FromObject(event.Object)
+				if apierrors.IsResourceExpired(err) || apierrors.IsGone(err) {
+					// the resource version of the list is too old; the caller lists the pods again
+					return nil
+				}
+				return fmt.Errorf("could not watch pods for listOptions %s: %w", m.podList.listOptions.String(), err)
+			}
+			if event.Type != watch.Added && event.Type != watch.Modified {
+				continue
+			}
+			if pod, isPod := event.Object.(*v1.Pod); isPod {
+				m.followPod(ctx, pod)
+			}
+		}
+	}
+}
+
+func (m *logMultiplexer) followPod(ctx context.Context, pod *v1.Pod) {
+	for _, status := range pod.Status.ContainerStatuses {
+		hasStarted := status.State.Running != nil || status.State.Terminated != nil
+		if !hasStarted {
+			continue
+		}
+
+		instanceID := status.ContainerID
+		if instanceID == "" {
+			instanceID = fmt.Sprintf("%s/%d", podContainerKey(pod.Name, status.Name), status.RestartCount)
+		}
+		if m.started[instanceID] {
+			continue
+		}
+		m.started[instanceID] = true
+
+		m.wg.Add(1)
+		go func(podName, container string) {
+			defer m.wg.Done()
+			m.followContainer(ctx, podName, container)
+		}(pod.Name, status.Name)
+	}
+}
+
+func (m *logMultiplexer) followContainer(ctx context.Context, podName, container string) {
+	prefix := fmt.Sprintf("[%s] ", podContainerKey(podName, container))
+
+	podLogOpts := newPodLogOptions(m.opts...)
+	podLogOpts.Container = container
+	podLogOpts.Follow = true
+
+	stream, err := m.podList.podClient.GetLogs(podName, podLogOpts).Stream(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			m.writeLine(prefix, fmt.Sprintf("could not follow logs: %s", err.Error()))
+		}
+		return
+	}
+	defer stream.Close()
+
+	scanner := bufio.NewScanner(stream)
+	for scanner.Scan() {
+		m.writeLine(prefix, scanner.Text())
+	}
+}
+
+func (m *logMultiplexer) writeLine(prefix, line string) {
+	m.outMu.Lock()
+	defer m.outMu.Unlock()
+	_, _ = io.WriteString(m.out, prefix+line+"\n")
+}
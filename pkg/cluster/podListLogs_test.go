@@ -0,0 +1,191 @@
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// syncBuffer allows reading a buffer while log streams write into it.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (sb *syncBuffer) Write(p []byte) (int, error) {
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+	return sb.buf.Write(p)
+}
+
+func (sb *syncBuffer) String() string {
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+	return sb.buf.String()
+}
+
+func runningPod(name string, containers ...string) *corev1.Pod {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: DefaultNamespace,
+			Labels:    map[string]string{"app": "nginx"},
+		},
+	}
+	for _, container := range containers {
+		pod.Spec.Containers = append(pod.Spec.Containers, corev1.Container{Name: container})
+		pod.Status.ContainerStatuses = append(pod.Status.ContainerStatuses, corev1.ContainerStatus{
+			Name:        container,
+			ContainerID: "containerd://" + name + "-" + container,
+			State:       corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+		})
+	}
+	return pod
+}
+
+// notifyOnWatch signals the returned channel as soon as a pod watch was established.
+func notifyOnWatch(clientSet *fake.Clientset) <-chan struct{} {
+	watching := make(chan struct{})
+	var once sync.Once
+	clientSet.PrependWatchReactor("pods", func(action k8stesting.Action) (bool, watch.Interface, error) {
+		watcher, err := clientSet.Tracker().Watch(action.GetResource(), action.GetNamespace())
+		once.Do(func() { close(watching) })
+		return true, watcher, err
+	})
+	return watching
+}
+
+func TestPodList_Logs(t *testing.T) {
+	// given
+	clientSet := fake.NewSimpleClientset(runningPod("nginx-1", "nginx", "sidecar"), runningPod("nginx-2", "nginx"))
+	sut := (&PodListSelector{podClient: clientSet.CoreV1().Pods(DefaultNamespace)}).ByLabels("app=nginx").List()
+
+	// when
+	actual, err := sut.Logs(context.Background(), WithLogTailLines(5))
+
+	// then
+	require.NoError(t, err)
+	expected := map[string][]byte{
+		"nginx-1/nginx":   []byte("fake logs"),
+		"nginx-1/sidecar": []byte("fake logs"),
+		"nginx-2/nginx":   []byte("fake logs"),
+	}
+	assert.Equal(t, expected, actual)
+}
+
+func TestPodList_StreamLogs(t *testing.T) {
+	// given
+	clientSet := fake.NewSimpleClientset(runningPod("nginx-1", "nginx"))
+	watching := notifyOnWatch(clientSet)
+	podClient := clientSet.CoreV1().Pods(DefaultNamespace)
+	sut := (&PodListSelector{podClient: podClient}).ByLabels("app=nginx").List()
+	out := &syncBuffer{}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// when
+	errCh := make(chan error)
+	go func() {
+		errCh <- sut.StreamLogs(ctx, out)
+	}()
+
+	// then
+	assert.Eventually(t, func() bool {
+		return out.String() == "[nginx-1/nginx] fake logs\n"
+	}, 5*time.Second, 10*time.Millisecond)
+	<-watching
+
+	_, err := podClient.Create(ctx, runningPod("nginx-2", "nginx"), metav1.CreateOptions{})
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		return strings.Contains(out.String(), "[nginx-2/nginx] fake logs\n")
+	}, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	require.NoError(t, <-errCh)
+	assert.Equal(t, 2, strings.Count(out.String(), "\n"))
+}
+
+// countPodLists counts the pod lists and answers every pod watch with the given events before closing it.
+func countPodLists(clientSet *fake.Clientset, events ...watch.Event) *atomic.Int32 {
+	lists := &atomic.Int32{}
+	clientSet.PrependReactor("list", "pods", func(k8stesting.Action) (bool, runtime.Object, error) {
+		lists.Add(1)
+		return false, nil, nil
+	})
+	clientSet.PrependWatchReactor("pods", func(k8stesting.Action) (bool, watch.Interface, error) {
+		watcher := watch.NewFakeWithChanSize(len(events), false)
+		for _, event := range events {
+			watcher.Action(event.Type, event.Object)
+		}
+		watcher.Stop()
+		return true, watcher, nil
+	})
+	return lists
+}
+
+func TestPodList_StreamLogs_watchEnds(t *testing.T) {
+	t.Run("should list again after a delay when the watch closes", func(t *testing.T) {
+		// given
+		clientSet := fake.NewSimpleClientset()
+		lists := countPodLists(clientSet)
+		sut := (&PodListSelector{podClient: clientSet.CoreV1().Pods(DefaultNamespace)}).ByLabels("app=nginx").List()
+		ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+		defer cancel()
+
+		// when
+		err := sut.StreamLogs(ctx, &syncBuffer{})
+
+		// then
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, lists.Load(), int32(2))
+		assert.LessOrEqual(t, lists.Load(), int32(4))
+	})
+	t.Run("should list again when the resource version expired", func(t *testing.T) {
+		// given
+		clientSet := fake.NewSimpleClientset()
+		expired := apierrors.NewResourceExpired("too old resource version")
+		lists := countPodLists(clientSet, watch.Event{Type: watch.Error, Object: &expired.ErrStatus})
+		sut := (&PodListSelector{podClient: clientSet.CoreV1().Pods(DefaultNamespace)}).ByLabels("app=nginx").List()
+		ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+		defer cancel()
+
+		// when
+		err := sut.StreamLogs(ctx, &syncBuffer{})
+
+		// then
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, lists.Load(), int32(2))
+	})
+	t.Run("should return other watch errors", func(t *testing.T) {
+		// given
+		clientSet := fake.NewSimpleClientset()
+		forbidden := apierrors.NewForbidden(schema.GroupResource{Resource: "pods"}, "", errors.New("watch not allowed"))
+		countPodLists(clientSet, watch.Event{Type: watch.Error, Object: &forbidden.ErrStatus})
+		sut := (&PodListSelector{podClient: clientSet.CoreV1().Pods(DefaultNamespace)}).ByLabels("app=nginx").List()
+
+		// when
+		err := sut.StreamLogs(context.Background(), &syncBuffer{})
+
+		// then
+		require.Error(t, err)
+		assert.True(t, apierrors.IsForbidden(err))
+		assert.ErrorContains(t, err, "could not watch pods for listOptions")
+	})
+}
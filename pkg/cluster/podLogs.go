@@ -0,0 +1,127 @@
+package cluster
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// LogOption modifies the options with which container logs are requested from the kubernetes API.
+type LogOption func(opts *corev1.PodLogOptions)
+
+// WithLogContainer selects the container whose logs should be returned. It is required for pods with more than one
+// container.
+func WithLogContainer(container string) LogOption {
+	return func(opts *corev1.PodLogOptions) {
+		opts.Container = container
+	}
+}
+
+// WithPreviousLogs returns the logs of the previously terminated container instance, f. i. after a crash loop.
+func WithPreviousLogs() LogOption {
+	return func(opts *corev1.PodLogOptions) {
+		opts.Previous = true
+	}
+}
+
+// WithLogsSince only returns log lines that are newer than the given duration.
+func WithLogsSince(since time.Duration) LogOption {
+	return func(opts *corev1.PodLogOptions) {
+		seconds := int64(since.Seconds())
+		opts.SinceSeconds = &seconds
+		opts.SinceTime = nil
+	}
+}
+
+// WithLogsSinceTime only returns log lines that were written after the given point in time.
+func WithLogsSinceTime(since time.Time) LogOption {
+	return func(opts *corev1.PodLogOptions) {
+		sinceTime := metav1.NewTime(since)
+		opts.SinceTime = &sinceTime
+		opts.SinceSeconds = nil
+	}
+}
+
+// WithLogTailLines only returns the last n lines of the log.
+func WithLogTailLines(lines int64) LogOption {
+	return func(opts *corev1.PodLogOptions) {
+		opts.TailLines = &lines
+	}
+}
+
+// WithLogTimestamps prefixes every log line with an RFC3339 timestamp.
+func WithLogTimestamps() LogOption {
+	return func(opts *corev1.PodLogOptions) {
+		opts.Timestamps = true
+	}
+}
+
+func newPodLogOptions(opts ...LogOption) *corev1.PodLogOptions {
+	podLogOpts := &corev1.PodLogOptions{}
+	for _, opt := range opts {
+		opt(podLogOpts)
+	}
+	return podLogOpts
+}
+
+// StreamLogs follows the pod's log and writes it to the given writer. It blocks until the container terminates or the
+// context is done. A cancelled context is not reported as error because it is the usual way to stop following.
+func (ps *PodSelector) StreamLogs(ctx context.Context, w io.Writer, opts ...LogOption) error {
+	stream, err := ps.followLogs(ctx, opts...)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	_, err = io.Copy(w, stream)
+	if err != nil && ctx.Err() == nil {
+		return fmt.Errorf("could not stream logs of pod %s: %w", ps.name, err)
+	}
+
+	return nil
+}
+
+// WaitForLogLine follows the pod's log until a line matches the given regular expression and returns that line. An
+// error is returned if the log ends or the context is done before a matching line appeared.
+func (ps *PodSelector) WaitForLogLine(ctx context.Context, expr *regexp.Regexp, opts ...LogOption) (string, error) {
+	stream, err := ps.followLogs(ctx, opts...)
+	if err != nil {
+		return "", err
+	}
+	defer stream.Close()
+
+	scanner := bufio.NewScanner(stream)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if expr.MatchString(line) {
+			return line, nil
+		}
+	}
+
+	if ctx.Err() != nil {
+		return "", fmt.Errorf("stopped waiting for log line matching %q in pod %s: %w", expr.String(), ps.name, ctx.Err())
+	}
+	if scanner.Err() != nil {
+		return "", fmt.Errorf("could not read logs of pod %s: %w", ps.name, scanner.Err())
+	}
+
+	return "", fmt.Errorf("log of pod %s ended without a line matching %q", ps.name, expr.String())
+}
+
+func (ps *PodSelector) followLogs(ctx context.Context, opts ...LogOption) (io.ReadCloser, error) {
+	podLogOpts := newPodLogOptions(opts...)
+	podLogOpts.Follow = true
+
+	stream, err := ps.podClient.GetLogs(ps.name, podLogOpts).Stream(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not follow logs of pod %s: %w", ps.name, err)
+	}
+
+	return stream, nil
+}
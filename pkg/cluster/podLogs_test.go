@@ -0,0 +1,116 @@
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func newFakePodSelector(name string) (*PodSelector, *fake.Clientset) {
+	clientSet := fake.NewSimpleClientset()
+	return &PodSelector{
+		podClient:   clientSet.CoreV1().Pods(DefaultNamespace),
+		eventClient: clientSet.CoreV1().Events(DefaultNamespace),
+		name:        name,
+	}, clientSet
+}
+
+func requestedLogOptions(t *testing.T, clientSet *fake.Clientset) *corev1.PodLogOptions {
+	t.Helper()
+	actions := clientSet.Actions()
+	require.Len(t, actions, 1)
+	opts, ok := actions[0].(k8stesting.GenericAction).GetValue().(*corev1.PodLogOptions)
+	require.True(t, ok)
+	return opts
+}
+
+func TestPodSelector_Logs(t *testing.T) {
+	t.Run("should apply log options", func(t *testing.T) {
+		// given
+		sut, clientSet := newFakePodSelector("echo-pod")
+		since := time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+
+		// when
+		actual, err := sut.Logs(context.Background(),
+			WithLogContainer("sidecar"),
+			WithPreviousLogs(),
+			WithLogsSinceTime(since),
+			WithLogTailLines(10),
+			WithLogTimestamps(),
+		)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "fake logs", string(actual))
+		opts := requestedLogOptions(t, clientSet)
+		assert.Equal(t, "sidecar", opts.Container)
+		assert.True(t, opts.Previous)
+		assert.True(t, opts.Timestamps)
+		assert.False(t, opts.Follow)
+		assert.Equal(t, int64(10), *opts.TailLines)
+		assert.True(t, since.Equal(opts.SinceTime.Time))
+		assert.Nil(t, opts.SinceSeconds)
+	})
+	t.Run("should replace since time with since seconds", func(t *testing.T) {
+		// given
+		sut, clientSet := newFakePodSelector("echo-pod")
+
+		// when
+		_, err := sut.Logs(context.Background(), WithLogsSinceTime(time.Now()), WithLogsSince(90*time.Second))
+
+		// then
+		require.NoError(t, err)
+		opts := requestedLogOptions(t, clientSet)
+		assert.Nil(t, opts.SinceTime)
+		assert.Equal(t, int64(90), *opts.SinceSeconds)
+	})
+}
+
+func TestPodSelector_StreamLogs(t *testing.T) {
+	// given
+	sut, clientSet := newFakePodSelector("echo-pod")
+	buf := &bytes.Buffer{}
+
+	// when
+	err := sut.StreamLogs(context.Background(), buf, WithLogContainer("alpine"))
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, "fake logs", buf.String())
+	opts := requestedLogOptions(t, clientSet)
+	assert.True(t, opts.Follow)
+	assert.Equal(t, "alpine", opts.Container)
+}
+
+func TestPodSelector_WaitForLogLine(t *testing.T) {
+	t.Run("should return matching line", func(t *testing.T) {
+		// given
+		sut, _ := newFakePodSelector("echo-pod")
+
+		// when
+		actual, err := sut.WaitForLogLine(context.Background(), regexp.MustCompile("^fake"))
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "fake logs", actual)
+	})
+	t.Run("should fail when log ends without match", func(t *testing.T) {
+		// given
+		sut, _ := newFakePodSelector("echo-pod")
+
+		// when
+		_, err := sut.WaitForLogLine(context.Background(), regexp.MustCompile("hello world"))
+
+		// then
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "log of pod echo-pod ended without a line matching \"hello world\"")
+	})
+}
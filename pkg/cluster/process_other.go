@@ -0,0 +1,15 @@
+//go:build !unix
+
+package cluster
+
+import "os"
+
+// processExists checks whether a process with the given PID runs on this host.
+func processExists(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	_ = process.Release()
+	return true
+}
@@ -0,0 +1,128 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+)
+
+const defaultReadinessTimeout = 2 * time.Minute
+const readinessPollInterval = 500 * time.Millisecond
+
+// ReadinessGate is a condition which must be met before a newly created cluster is handed out.
+type ReadinessGate struct {
+	// Name identifies the gate in error messages.
+	Name string
+	// Check returns nil if the condition is met. Otherwise, the returned error describes why the condition is not met
+	// yet. Check is called repeatedly until it succeeds or the readiness timeout is reached.
+	Check func(ctx context.Context, clientSet kubernetes.Interface) error
+}
+
+// DefaultServiceAccountGate waits until the `default` service account of the default namespace exists. Pods cannot
+// be created in the namespace before. It is part of every cluster's readiness gates.
+var DefaultServiceAccountGate = ReadinessGate{
+	Name: "default service account",
+	Check: func(ctx context.Context, clientSet kubernetes.Interface) error {
+		_, err := clientSet.CoreV1().ServiceAccounts(DefaultNamespace).Get(ctx, "default", metav1.GetOptions{})
+		return err
+	},
+}
+
+// Readiness gates for the components that k3s bundles.
+var (
+	CoreDNSGate              = DeploymentReadyGate("kube-system", "coredns")
+	MetricsServerGate        = DeploymentReadyGate("kube-system", "metrics-server")
+	LocalPathProvisionerGate = DeploymentReadyGate("kube-system", "local-path-provisioner")
+	TraefikGate              = DeploymentReadyGate("kube-system", "traefik")
+)
+
+// SystemComponentGates returns the readiness gates of all components that k3s bundles.
+func SystemComponentGates() []ReadinessGate {
+	return []ReadinessGate{CoreDNSGate, MetricsServerGate, LocalPathProvisionerGate, TraefikGate}
+}
+
+// DeploymentReadyGate waits until the given deployment exists and all of its replicas are updated and ready. The gate is
+// named `<namespace>/<name>`.
+func DeploymentReadyGate(namespace, name string) ReadinessGate {
+	return ReadinessGate{
+		Name: namespace + "/" + name,
+		Check: func(ctx context.Context, clientSet kubernetes.Interface) error {
+			deployment, err := clientSet.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+
+			desired := int32(1)
+			if deployment.Spec.Replicas != nil {
+				desired = *deployment.Spec.Replicas
+			}
+			status := deployment.Status
+			if status.ObservedGeneration < deployment.Generation || status.UpdatedReplicas < desired || status.ReadyReplicas < desired {
+				return fmt.Errorf("deployment %s/%s has %d/%d ready replicas", namespace, name, status.ReadyReplicas, desired)
+			}
+			return nil
+		},
+	}
+}
+
+// WithReadinessGates adds gates which the cluster must pass before it is handed out. Use SystemComponentGates to wait
+// for the components that k3s bundles, or define custom gates.
+func WithReadinessGates(gates ...ReadinessGate) ClusterOption {
+	return func(opts *clusterOptions) {
+		opts.readinessGates = append(opts.readinessGates, gates...)
+	}
+}
+
+// WithReadinessTimeout sets how long to wait for all readiness gates to pass. The default is two minutes.
+func WithReadinessTimeout(timeout time.Duration) ClusterOption {
+	return func(opts *clusterOptions) {
+		opts.readinessTimeout = timeout
+	}
+}
+
+// waitForReadiness checks the gates until all of them passed. If the timeout is reached, the returned error names the
+// gates that did not pass together with their last failure. Gates are tracked by position, so gates may share a name.
+func waitForReadiness(ctx context.Context, clientSet kubernetes.Interface, gates []ReadinessGate, timeout time.Duration) error {
+	passed := make([]bool, len(gates))
+	lastErrors := make([]error, len(gates))
+
+	err := wait.PollUntilContextTimeout(ctx, readinessPollInterval, timeout, true, func(ctx context.Context) (bool, error) {
+		done := true
+		for i, gate := range gates {
+			if passed[i] {
+				continue
+			}
+			err := gate.Check(ctx, clientSet)
+			if err != nil {
+				lastErrors[i] = err
+				done = false
+				continue
+			}
+			passed[i] = true
+		}
+		return done, nil
+	})
+	if err == nil {
+		return nil
+	}
+
+	var failed []string
+	for i, gate := range gates {
+		if passed[i] {
+			continue
+		}
+		reason := "not checked"
+		if lastErrors[i] != nil {
+			reason = lastErrors[i].Error()
+		}
+		failed = append(failed, fmt.Sprintf("%s (%s)", gate.Name, reason))
+	}
+
+	return fmt.Errorf("cluster did not become ready within %s, readiness gates not passed: %s: %w",
+		timeout, strings.Join(failed, ", "), err)
+}
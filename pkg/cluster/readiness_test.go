@@ -0,0 +1,113 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func deployment(name string, replicas, ready int32) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "kube-system"},
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+		Status:     appsv1.DeploymentStatus{UpdatedReplicas: replicas, ReadyReplicas: ready},
+	}
+}
+
+func TestDeploymentReadyGate(t *testing.T) {
+	tests := []struct {
+		name    string
+		objects []*appsv1.Deployment
+		wantErr string
+	}{
+		{"missing deployment", nil, `deployments.apps "coredns" not found`},
+		{"unready deployment", []*appsv1.Deployment{deployment("coredns", 2, 1)}, "deployment kube-system/coredns has 1/2 ready replicas"},
+		{"ready deployment", []*appsv1.Deployment{deployment("coredns", 2, 2)}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientSet := fake.NewSimpleClientset()
+			for _, obj := range tt.objects {
+				require.NoError(t, clientSet.Tracker().Add(obj))
+			}
+
+			err := CoreDNSGate.Check(context.Background(), clientSet)
+
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+func Test_waitForReadiness(t *testing.T) {
+	t.Run("should pass when all gates pass eventually", func(t *testing.T) {
+		// given
+		clientSet := fake.NewSimpleClientset(deployment("coredns", 1, 1))
+		calls := 0
+		eventualGate := ReadinessGate{
+			Name: "eventual",
+			Check: func(ctx context.Context, clientSet kubernetes.Interface) error {
+				calls++
+				if calls < 2 {
+					return errors.New("not yet")
+				}
+				return nil
+			},
+		}
+		serviceAccount := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: DefaultNamespace}}
+		require.NoError(t, clientSet.Tracker().Add(serviceAccount))
+
+		// when
+		err := waitForReadiness(context.Background(), clientSet, []ReadinessGate{DefaultServiceAccountGate, CoreDNSGate, eventualGate}, 5*time.Second)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, 2, calls)
+	})
+	t.Run("should name gates that did not pass", func(t *testing.T) {
+		// given
+		clientSet := fake.NewSimpleClientset(deployment("coredns", 1, 1), deployment("traefik", 1, 0))
+
+		// when
+		err := waitForReadiness(context.Background(), clientSet, append([]ReadinessGate{DefaultServiceAccountGate}, SystemComponentGates()...), time.Second)
+
+		// then
+		require.Error(t, err)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.ErrorContains(t, err, "cluster did not become ready within 1s, readiness gates not passed: "+
+			`default service account (serviceaccounts "default" not found), `+
+			`kube-system/metrics-server (deployments.apps "metrics-server" not found), `+
+			`kube-system/local-path-provisioner (deployments.apps "local-path-provisioner" not found), `+
+			"kube-system/traefik (deployment kube-system/traefik has 0/1 ready replicas)")
+		assert.NotContains(t, err.Error(), "coredns")
+	})
+	t.Run("should check gates with the same name separately", func(t *testing.T) {
+		// given
+		clientSet := fake.NewSimpleClientset(deployment("coredns", 1, 1))
+		gates := []ReadinessGate{
+			{Name: "kube-system/coredns", Check: func(context.Context, kubernetes.Interface) error {
+				return errors.New("still starting")
+			}},
+			DeploymentReadyGate("kube-system", "coredns"),
+		}
+
+		// when
+		err := waitForReadiness(context.Background(), clientSet, gates, time.Second)
+
+		// then
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "readiness gates not passed: kube-system/coredns (still starting): ")
+	})
+}
@@ -0,0 +1,93 @@
+package cluster
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// k3s versions
+// warning: k3s versions are tagged with a `+` separator before `k3s1`, but k3s images use `-`.
+const (
+	K3sVersion1_26 = "v1.26.2-k3s1"
+	K3sVersion1_27 = "v1.27.16-k3s1"
+	K3sVersion1_28 = "v1.28.2-k3s1"
+	K3sVersion1_29 = "v1.29.7-k3s1"
+	K3sVersion1_30 = "v1.30.3-k3s1"
+)
+
+// DefaultKubernetesVersion is the Kubernetes version of clusters unless another version is configured.
+const DefaultKubernetesVersion = "1.28"
+
+// KubernetesVersionEnv names the environment variable that sets the Kubernetes version of all clusters which do not
+// configure a version with WithKubernetesVersion, f. e. `1.29`.
+const KubernetesVersionEnv = "TESTCLUSTERS_KUBERNETES_VERSION"
+
+// k3sVersions maps the supported Kubernetes minor versions to the k3s image tags that provide them.
+var k3sVersions = map[string]string{
+	"1.26": K3sVersion1_26,
+	"1.27": K3sVersion1_27,
+	"1.28": K3sVersion1_28,
+	"1.29": K3sVersion1_29,
+	"1.30": K3sVersion1_30,
+}
+
+// SupportedKubernetesVersions returns the Kubernetes minor versions that can be passed to WithKubernetesVersion,
+// starting with the oldest.
+func SupportedKubernetesVersions() []string {
+	versions := make([]string, 0, len(k3sVersions))
+	for version := range k3sVersions {
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return minorVersion(versions[i]) < minorVersion(versions[j])
+	})
+	return versions
+}
+
+// K3sVersionFor returns the k3s image tag that provides the given Kubernetes minor version like `1.29` or `v1.29`.
+func K3sVersionFor(kubernetesVersion string) (string, error) {
+	k3sVersion, ok := k3sVersions[strings.TrimPrefix(kubernetesVersion, "v")]
+	if !ok {
+		return "", fmt.Errorf("unsupported Kubernetes version %q, supported versions are %s",
+			kubernetesVersion, strings.Join(SupportedKubernetesVersions(), ", "))
+	}
+	return k3sVersion, nil
+}
+
+// WithKubernetesVersion creates a cluster of the given Kubernetes minor version like `1.29`. See
+// SupportedKubernetesVersions for the available versions.
+func WithKubernetesVersion(version string) ClusterOption {
+	return func(opts *clusterOptions) {
+		opts.kubernetesVersion = version
+	}
+}
+
+// ForEachVersion runs the test as a subtest per Kubernetes version, each with its own cluster. The subtests are named
+// after the versions. If no versions are given, the test runs against all supported versions.
+func ForEachVersion(t *testing.T, versions []string, test func(t *testing.T, c *K3dCluster), opts ...ClusterOption) {
+	if len(versions) == 0 {
+		versions = SupportedKubernetesVersions()
+	}
+
+	for _, version := range versions {
+		version := version
+		t.Run(version, func(t *testing.T) {
+			if _, err := K3sVersionFor(version); err != nil {
+				t.Fatal(err.Error())
+			}
+
+			c := NewK3dCluster(t, append(opts[:len(opts):len(opts)], WithKubernetesVersion(version))...)
+			test(t, c)
+		})
+	}
+}
+
+// minorVersion returns the minor part of a version like `1.29`.
+func minorVersion(version string) int {
+	_, minor, _ := strings.Cut(version, ".")
+	number, _ := strconv.Atoi(minor)
+	return number
+}
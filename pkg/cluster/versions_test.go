@@ -0,0 +1,62 @@
+package cluster
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSupportedKubernetesVersions(t *testing.T) {
+	assert.Equal(t, []string{"1.26", "1.27", "1.28", "1.29", "1.30"}, SupportedKubernetesVersions())
+}
+
+func TestK3sVersionFor(t *testing.T) {
+	t.Run("should resolve minor version", func(t *testing.T) {
+		version, err := K3sVersionFor("1.29")
+
+		require.NoError(t, err)
+		assert.Equal(t, K3sVersion1_29, version)
+	})
+	t.Run("should accept v prefix", func(t *testing.T) {
+		version, err := K3sVersionFor("v1.26")
+
+		require.NoError(t, err)
+		assert.Equal(t, K3sVersion1_26, version)
+	})
+	t.Run("should fail on unsupported version", func(t *testing.T) {
+		_, err := K3sVersionFor("1.12")
+
+		assert.EqualError(t, err, `unsupported Kubernetes version "1.12", supported versions are 1.26, 1.27, 1.28, 1.29, 1.30`)
+	})
+}
+
+func TestWithKubernetesVersion(t *testing.T) {
+	t.Run("should default to the default version", func(t *testing.T) {
+		t.Setenv(KubernetesVersionEnv, "")
+
+		options := newClusterOptions()
+
+		assert.Equal(t, DefaultKubernetesVersion, options.kubernetesVersion)
+	})
+	t.Run("should read the version from the environment", func(t *testing.T) {
+		t.Setenv(KubernetesVersionEnv, "1.27")
+
+		options := newClusterOptions()
+
+		assert.Equal(t, "1.27", options.kubernetesVersion)
+	})
+	t.Run("should prefer the option over the environment", func(t *testing.T) {
+		t.Setenv(KubernetesVersionEnv, "1.27")
+
+		options := newClusterOptions(WithKubernetesVersion("1.30"))
+
+		assert.Equal(t, "1.30", options.kubernetesVersion)
+	})
+}
+
+func Test_newSimpleConfig_image(t *testing.T) {
+	config := newSimpleConfig("my-cluster", K3sVersion1_30, 6443, newClusterOptions())
+
+	assert.Equal(t, "docker.io/rancher/k3s:v1.30.3-k3s1", config.Image)
+}
@@ -3,8 +3,15 @@ package cluster
 import (
 	"context"
 	"fmt"
+	"io/fs"
+	"os"
 
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
 
 	"github.com/cloudogu/k8s-apply-lib/apply"
 )
@@ -15,23 +22,166 @@ type kubeApplier interface {
 
 // YamlApplier provides a pod with kubectl access to the cluster.
 type YamlApplier struct {
-	applier          kubeApplier
+	applier kubeApplier
+	// newApplier replaces the applier when its discovery information is outdated.
+	newApplier       func() (kubeApplier, error)
+	fieldManager     string
+	dynamicClient    dynamic.Interface
+	mapper           meta.ResettableRESTMapper
 	defaultNamespace string
+	// overrideNamespace applies namespaced resources to the default namespace even if they name another namespace.
+	overrideNamespace bool
+	// clusterName and registry describe the cluster for templates, see ApplyTemplate.
+	clusterName string
+	registry    string
 }
 
-func NewYamlApplier(restConfig *rest.Config, fieldManager, defaultNamespace string) (*YamlApplier, error) {
+// ApplierOption customizes a YamlApplier.
+type ApplierOption func(applier *YamlApplier)
+
+// WithNamespace applies namespaced resources without namespace to the given namespace instead of the default namespace.
+// The namespace must exist.
+func WithNamespace(namespace string) ApplierOption {
+	return func(applier *YamlApplier) {
+		applier.defaultNamespace = namespace
+	}
+}
+
+// WithNamespaceOverride applies all namespaced resources to the applier's namespace, even if they name another
+// namespace. This allows to install the same manifests into several namespaces of a cluster.
+func WithNamespaceOverride() ApplierOption {
+	return func(applier *YamlApplier) {
+		applier.overrideNamespace = true
+	}
+}
+
+func NewYamlApplier(restConfig *rest.Config, fieldManager, defaultNamespace string, opts ...ApplierOption) (*YamlApplier, error) {
 	applier, _, err := apply.New(restConfig, fieldManager)
 	if err != nil {
 		return nil, fmt.Errorf("could not create applier: %w", err)
 	}
-	return &YamlApplier{applier: applier, defaultNamespace: defaultNamespace}, nil
+	dynamicClient, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("could not create dynamic client: %w", err)
+	}
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("could not create discovery client: %w", err)
+	}
+
+	yamlApplier := &YamlApplier{
+		applier: applier,
+		newApplier: func() (kubeApplier, error) {
+			applier, _, err := apply.New(restConfig, fieldManager)
+			return applier, err
+		},
+		fieldManager:     fieldManager,
+		dynamicClient:    dynamicClient,
+		mapper:           restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(discoveryClient)),
+		defaultNamespace: defaultNamespace,
+	}
+	for _, opt := range opts {
+		opt(yamlApplier)
+	}
+	return yamlApplier, nil
 }
 
-// ApplyWithFile copies the yaml bytes into a file and calls `kubectl apply -f`.
+// ApplyWithFile applies all resources of a YAML manifest. Documents of a manifest are separated by `---`. Namespaces and
+// CustomResourceDefinitions are applied first, the other resources in the order of the manifest. Namespaced resources
+// without namespace are applied to the namespace of the applier, see WithNamespace and WithNamespaceOverride.
 func (ya *YamlApplier) ApplyWithFile(ctx context.Context, yamlBytes []byte) error {
-	err := ya.applier.Apply(yamlBytes, ya.defaultNamespace)
+	documents, err := splitYamlDocuments("", yamlBytes)
+	if err != nil {
+		return err
+	}
+	return ya.applyDocuments(ctx, documents)
+}
+
+// ApplyFS applies the YAML files of a file system like an embed.FS that match one of the given fs.Glob patterns, f. e.
+// `testdata/*.yaml`. Without patterns, all files ending with `.yaml` or `.yml` are applied. The files are read in
+// lexical order and applied like a single manifest, see ApplyWithFile.
+func (ya *YamlApplier) ApplyFS(ctx context.Context, fsys fs.FS, patterns ...string) error {
+	documents, err := readYamlFS(fsys, patterns)
 	if err != nil {
 		return err
 	}
+	return ya.applyDocuments(ctx, documents)
+}
+
+// ApplyDir applies all YAML files of a directory and its subdirectories, see ApplyFS.
+func (ya *YamlApplier) ApplyDir(ctx context.Context, path string) error {
+	return ya.ApplyFS(ctx, os.DirFS(path))
+}
+
+func (ya *YamlApplier) applyDocuments(ctx context.Context, documents []yamlDocument) error {
+	sortByApplyOrder(documents)
+
+	for _, document := range documents {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		err := ya.applyDocument(document)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ya *YamlApplier) applyDocument(document yamlDocument) error {
+	err := ya.applier.Apply(document.content, ya.namespaceOf(document))
+	if meta.IsNoMatchError(err) && ya.newApplier != nil {
+		// the kind may have been added by a CustomResourceDefinition after the applier was created
+		err = ya.refreshDiscovery()
+		if err == nil {
+			err = ya.applier.Apply(document.content, ya.namespaceOf(document))
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to apply %s: %w", document, err)
+	}
 	return nil
 }
+
+// refreshDiscovery drops the cached discovery information so that recently added kinds become known.
+func (ya *YamlApplier) refreshDiscovery() error {
+	if ya.mapper != nil {
+		ya.mapper.Reset()
+	}
+	applier, err := ya.newApplier()
+	if err != nil {
+		return fmt.Errorf("could not create applier: %w", err)
+	}
+	ya.applier = applier
+	return nil
+}
+
+// namespaceOf returns the namespace of a document or the default namespace if the document does not name one or the
+// namespace is overridden.
+func (ya *YamlApplier) namespaceOf(document yamlDocument) string {
+	if namespace := document.object.GetNamespace(); namespace != "" && !ya.overrideNamespace {
+		return namespace
+	}
+	return ya.defaultNamespace
+}
+
+// resourceFor returns a client for the resource of a document together with the namespace of the resource, which is
+// empty for cluster-scoped resources. The discovery information is refreshed once if the kind is unknown, f. e. because
+// its CustomResourceDefinition was applied recently.
+func (ya *YamlApplier) resourceFor(document yamlDocument) (dynamic.ResourceInterface, string, error) {
+	gvk := document.object.GroupVersionKind()
+	mapping, err := ya.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if meta.IsNoMatchError(err) {
+		ya.mapper.Reset()
+		mapping, err = ya.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("could not find resource of %s: %w", document, err)
+	}
+
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		namespace := ya.namespaceOf(document)
+		return ya.dynamicClient.Resource(mapping.Resource).Namespace(namespace), namespace, nil
+	}
+	return ya.dynamicClient.Resource(mapping.Resource), "", nil
+}
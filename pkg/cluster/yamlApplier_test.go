@@ -0,0 +1,242 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/cloudogu/k8s-apply-lib/apply"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/yaml"
+)
+
+type appliedDocument struct {
+	kind      string
+	name      string
+	namespace string
+}
+
+// recordingApplier records the applied documents and fails for documents with the given name.
+type recordingApplier struct {
+	applied  []appliedDocument
+	failName string
+}
+
+func (r *recordingApplier) Apply(yamlResource apply.YamlDocument, namespace string) error {
+	var meta struct {
+		Kind     string `json:"kind"`
+		Metadata struct {
+			Name string `json:"name"`
+		} `json:"metadata"`
+	}
+	if err := yaml.Unmarshal(yamlResource, &meta); err != nil {
+		return err
+	}
+	if meta.Metadata.Name == r.failName {
+		return errors.New("assert error")
+	}
+	r.applied = append(r.applied, appliedDocument{kind: meta.Kind, name: meta.Metadata.Name, namespace: namespace})
+	return nil
+}
+
+const multiDocumentManifest = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+  namespace: web
+---
+# only a comment
+---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: web
+---
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: my-widget
+---
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+`
+
+func TestYamlApplier_ApplyWithFile(t *testing.T) {
+	t.Run("should apply namespaces and CRDs first", func(t *testing.T) {
+		// given
+		applier := &recordingApplier{}
+		sut := &YamlApplier{applier: applier, defaultNamespace: "default"}
+
+		// when
+		err := sut.ApplyWithFile(context.Background(), []byte(multiDocumentManifest))
+
+		// then
+		require.NoError(t, err)
+		expected := []appliedDocument{
+			{kind: "Namespace", name: "web", namespace: "default"},
+			{kind: "CustomResourceDefinition", name: "widgets.example.com", namespace: "default"},
+			{kind: "Deployment", name: "nginx", namespace: "web"},
+			{kind: "Widget", name: "my-widget", namespace: "default"},
+			{kind: "ConfigMap", name: "config", namespace: "default"},
+		}
+		assert.Equal(t, expected, applier.applied)
+	})
+	t.Run("should name the failed document", func(t *testing.T) {
+		// given
+		applier := &recordingApplier{failName: "my-widget"}
+		sut := &YamlApplier{applier: applier, defaultNamespace: "default"}
+
+		// when
+		err := sut.ApplyWithFile(context.Background(), []byte(multiDocumentManifest))
+
+		// then
+		require.Error(t, err)
+		assert.EqualError(t, err, "failed to apply document 4 (Widget my-widget): assert error")
+		assert.Len(t, applier.applied, 3)
+	})
+	t.Run("should fail on invalid YAML", func(t *testing.T) {
+		// given
+		sut := &YamlApplier{applier: &recordingApplier{}, defaultNamespace: "default"}
+
+		// when
+		err := sut.ApplyWithFile(context.Background(), []byte("kind: Pod\n---\nkind: [Pod"))
+
+		// then
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "could not parse document 2")
+	})
+}
+
+func TestYamlApplier_ApplyFS(t *testing.T) {
+	manifests := fstest.MapFS{
+		"deploy/b-app.yaml":        {Data: []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: b\n---\napiVersion: v1\nkind: Namespace\nmetadata:\n  name: app\n")},
+		"deploy/a-app.yml":         {Data: []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\n")},
+		"deploy/nested/c-app.yaml": {Data: []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: c\n")},
+		"deploy/README.md":         {Data: []byte("# not a manifest")},
+	}
+
+	t.Run("should apply all YAML files in lexical order", func(t *testing.T) {
+		// given
+		applier := &recordingApplier{}
+		sut := &YamlApplier{applier: applier, defaultNamespace: "default"}
+
+		// when
+		err := sut.ApplyFS(context.Background(), manifests)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, []string{"app", "a", "b", "c"}, appliedNames(applier))
+	})
+	t.Run("should apply files matching the patterns", func(t *testing.T) {
+		// given
+		applier := &recordingApplier{}
+		sut := &YamlApplier{applier: applier, defaultNamespace: "default"}
+
+		// when
+		err := sut.ApplyFS(context.Background(), manifests, "deploy/nested/*.yaml", "deploy/*.yml", "deploy/a-*")
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a", "c"}, appliedNames(applier))
+	})
+	t.Run("should fail if a pattern matches nothing", func(t *testing.T) {
+		// given
+		sut := &YamlApplier{applier: &recordingApplier{}, defaultNamespace: "default"}
+
+		// when
+		err := sut.ApplyFS(context.Background(), manifests, "*.json")
+
+		// then
+		assert.EqualError(t, err, `no files match manifest pattern "*.json"`)
+	})
+	t.Run("should name the file of the failed document", func(t *testing.T) {
+		// given
+		sut := &YamlApplier{applier: &recordingApplier{failName: "b"}, defaultNamespace: "default"}
+
+		// when
+		err := sut.ApplyFS(context.Background(), manifests)
+
+		// then
+		assert.EqualError(t, err, "failed to apply document 1 (ConfigMap b) of deploy/b-app.yaml: assert error")
+	})
+}
+
+func TestYamlApplier_ApplyDir(t *testing.T) {
+	// given
+	applier := &recordingApplier{}
+	sut := &YamlApplier{applier: applier, defaultNamespace: "default"}
+
+	// when
+	err := sut.ApplyDir(context.Background(), "testdata")
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, []string{"echo-pod", "nginx-deployment", "nginx-svc", "nginx-deployment"}, appliedNames(applier))
+}
+
+func appliedNames(applier *recordingApplier) []string {
+	var names []string
+	for _, document := range applier.applied {
+		names = append(names, document.name)
+	}
+	return names
+}
+
+func TestYamlApplier_namespaces(t *testing.T) {
+	manifest := []byte(`
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: without-namespace
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: with-namespace
+  namespace: other
+`)
+
+	t.Run("should apply to the chosen namespace", func(t *testing.T) {
+		// given
+		applier := &recordingApplier{}
+		sut := &YamlApplier{applier: applier, defaultNamespace: DefaultNamespace}
+		WithNamespace("team")(sut)
+
+		// when
+		err := sut.ApplyWithFile(context.Background(), manifest)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, []appliedDocument{
+			{kind: "ConfigMap", name: "without-namespace", namespace: "team"},
+			{kind: "ConfigMap", name: "with-namespace", namespace: "other"},
+		}, applier.applied)
+	})
+	t.Run("should override namespaces of resources", func(t *testing.T) {
+		// given
+		applier := &recordingApplier{}
+		sut := &YamlApplier{applier: applier, defaultNamespace: DefaultNamespace}
+		WithNamespace("team")(sut)
+		WithNamespaceOverride()(sut)
+
+		// when
+		err := sut.ApplyWithFile(context.Background(), manifest)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, []appliedDocument{
+			{kind: "ConfigMap", name: "without-namespace", namespace: "team"},
+			{kind: "ConfigMap", name: "with-namespace", namespace: "team"},
+		}, applier.applied)
+	})
+}
@@ -0,0 +1,117 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+)
+
+const deletionPollInterval = 500 * time.Millisecond
+
+// DeleteOption customizes YamlApplier.Delete.
+type DeleteOption func(opts *deleteOptions)
+
+type deleteOptions struct {
+	propagationPolicy metav1.DeletionPropagation
+	waitTimeout       time.Duration
+}
+
+// WithPropagationPolicy sets whether and how the dependents of deleted objects are garbage collected. The default is
+// metav1.DeletePropagationBackground.
+func WithPropagationPolicy(policy metav1.DeletionPropagation) DeleteOption {
+	return func(opts *deleteOptions) {
+		opts.propagationPolicy = policy
+	}
+}
+
+// WithWaitForDeletion waits until all deleted objects are gone, f. e. until their finalizers are done. Delete fails if
+// an object still exists after the timeout.
+func WithWaitForDeletion(timeout time.Duration) DeleteOption {
+	return func(opts *deleteOptions) {
+		opts.waitTimeout = timeout
+	}
+}
+
+// Delete deletes all resources of a YAML manifest in the reverse order of ApplyWithFile. Resources that do not exist are
+// ignored, also if their kind is unknown because the CustomResourceDefinition was already deleted.
+func (ya *YamlApplier) Delete(ctx context.Context, yamlBytes []byte, opts ...DeleteOption) error {
+	options := &deleteOptions{propagationPolicy: metav1.DeletePropagationBackground}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	documents, err := splitYamlDocuments("", yamlBytes)
+	if err != nil {
+		return err
+	}
+	sortByApplyOrder(documents)
+
+	var deleted []deletedObject
+	for i := len(documents) - 1; i >= 0; i-- {
+		document := documents[i]
+		resource, _, err := ya.resourceFor(document)
+		if meta.IsNoMatchError(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		err = resource.Delete(ctx, document.object.GetName(), metav1.DeleteOptions{PropagationPolicy: &options.propagationPolicy})
+		if k8sErrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to delete %s: %w", document, err)
+		}
+		deleted = append(deleted, deletedObject{document: document, resource: resource})
+	}
+
+	if options.waitTimeout == 0 {
+		return nil
+	}
+	return waitForDeletion(ctx, deleted, options.waitTimeout)
+}
+
+type deletedObject struct {
+	document yamlDocument
+	resource dynamic.ResourceInterface
+}
+
+func waitForDeletion(ctx context.Context, objects []deletedObject, timeout time.Duration) error {
+	remaining := objects
+	err := wait.PollUntilContextTimeout(ctx, deletionPollInterval, timeout, true, func(ctx context.Context) (bool, error) {
+		var stillExisting []deletedObject
+		for _, object := range remaining {
+			_, err := object.resource.Get(ctx, object.document.object.GetName(), metav1.GetOptions{})
+			if k8sErrors.IsNotFound(err) {
+				continue
+			}
+			if err != nil {
+				return false, fmt.Errorf("failed to check deletion of %s: %w", object.document, err)
+			}
+			stillExisting = append(stillExisting, object)
+		}
+		remaining = stillExisting
+		return len(remaining) == 0, nil
+	})
+	if err == nil {
+		return nil
+	}
+	if !wait.Interrupted(err) {
+		return err
+	}
+
+	var errs []error
+	for _, object := range remaining {
+		errs = append(errs, fmt.Errorf("%s still exists", object.document))
+	}
+	return fmt.Errorf("resources were not deleted within %s: %w", timeout, errors.Join(append(errs, err)...))
+}
@@ -0,0 +1,156 @@
+package cluster
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicFake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// resettableMapper adds a no-op Reset to a static REST mapper.
+type resettableMapper struct {
+	meta.RESTMapper
+}
+
+func (m resettableMapper) Reset() {}
+
+func newTestMapper() meta.ResettableRESTMapper {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Namespace"}, meta.RESTScopeRoot)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Pod"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Group: "batch", Version: "v1", Kind: "Job"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Widget"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Group: "apiextensions.k8s.io", Version: "v1", Kind: "CustomResourceDefinition"}, meta.RESTScopeRoot)
+	return resettableMapper{mapper}
+}
+
+func newTestObject(apiVersion, kind, namespace, name string) *unstructured.Unstructured {
+	object := &unstructured.Unstructured{}
+	object.SetAPIVersion(apiVersion)
+	object.SetKind(kind)
+	object.SetNamespace(namespace)
+	object.SetName(name)
+	return object
+}
+
+func newTestDynamicClient(objects ...runtime.Object) *dynamicFake.FakeDynamicClient {
+	listKinds := map[schema.GroupVersionResource]string{
+		{Version: "v1", Resource: "namespaces"}:                                               "NamespaceList",
+		{Version: "v1", Resource: "configmaps"}:                                               "ConfigMapList",
+		{Group: "apps", Version: "v1", Resource: "deployments"}:                               "DeploymentList",
+		{Version: "v1", Resource: "pods"}:                                                     "PodList",
+		{Group: "batch", Version: "v1", Resource: "jobs"}:                                     "JobList",
+		{Group: "example.com", Version: "v1", Resource: "widgets"}:                            "WidgetList",
+		{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"}: "CustomResourceDefinitionList",
+	}
+	return dynamicFake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds, objects...)
+}
+
+const deleteManifest = `
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: web
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+  namespace: web
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: missing
+`
+
+func TestYamlApplier_Delete(t *testing.T) {
+	t.Run("should delete in reverse apply order and ignore missing objects", func(t *testing.T) {
+		// given
+		client := newTestDynamicClient(
+			newTestObject("v1", "Namespace", "", "web"),
+			newTestObject("apps/v1", "Deployment", "web", "nginx"),
+		)
+		sut := &YamlApplier{dynamicClient: client, mapper: newTestMapper(), defaultNamespace: "default"}
+
+		// when
+		err := sut.Delete(context.Background(), []byte(deleteManifest), WithPropagationPolicy(metav1.DeletePropagationForeground))
+
+		// then
+		require.NoError(t, err)
+		var deleted []string
+		for _, action := range client.Actions() {
+			deleteAction := action.(k8stesting.DeleteAction)
+			deleted = append(deleted, deleteAction.GetResource().Resource+"/"+deleteAction.GetName())
+		}
+		assert.Equal(t, []string{"configmaps/missing", "deployments/nginx", "namespaces/web"}, deleted)
+	})
+	t.Run("should ignore objects of unknown kinds", func(t *testing.T) {
+		// given
+		client := newTestDynamicClient(newTestObject("v1", "ConfigMap", "default", "settings"))
+		sut := &YamlApplier{dynamicClient: client, mapper: newTestMapper(), defaultNamespace: "default"}
+		manifest := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: settings\n---\n" +
+			"apiVersion: example.com/v1\nkind: Gadget\nmetadata:\n  name: removed-crd\n"
+
+		// when
+		err := sut.Delete(context.Background(), []byte(manifest))
+
+		// then
+		require.NoError(t, err)
+		require.Len(t, client.Actions(), 1)
+		assert.Equal(t, "settings", client.Actions()[0].(k8stesting.DeleteAction).GetName())
+	})
+	t.Run("should wait until objects are gone", func(t *testing.T) {
+		// given
+		client := newTestDynamicClient(newTestObject("apps/v1", "Deployment", "web", "nginx"))
+		client.PrependReactor("delete", "deployments", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			// keep the object like a finalizer would
+			return true, nil, nil
+		})
+		gets := 0
+		client.PrependReactor("get", "deployments", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			gets++
+			if gets == 2 {
+				// the finalizer is done
+				err := client.Tracker().Delete(action.GetResource(), "web", "nginx")
+				return err != nil, nil, err
+			}
+			return false, nil, nil
+		})
+		sut := &YamlApplier{dynamicClient: client, mapper: newTestMapper(), defaultNamespace: "default"}
+
+		// when
+		err := sut.Delete(context.Background(), []byte(deleteManifest), WithWaitForDeletion(5*time.Second))
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, 2, gets)
+	})
+	t.Run("should fail if objects still exist after the timeout", func(t *testing.T) {
+		// given
+		client := newTestDynamicClient(newTestObject("apps/v1", "Deployment", "web", "nginx"))
+		client.PrependReactor("delete", "deployments", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, nil, nil
+		})
+		sut := &YamlApplier{dynamicClient: client, mapper: newTestMapper(), defaultNamespace: "default"}
+
+		// when
+		err := sut.Delete(context.Background(), []byte(deleteManifest), WithWaitForDeletion(time.Second))
+
+		// then
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "resources were not deleted within 1s: document 2 (Deployment nginx) still exists")
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
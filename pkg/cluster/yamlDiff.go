@@ -0,0 +1,227 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// ignoredDiffFields are changed by every apply and therefore excluded from diffs.
+var ignoredDiffFields = [][]string{
+	{"metadata", "managedFields"},
+	{"metadata", "resourceVersion"},
+	{"metadata", "generation"},
+}
+
+// ResourceDiff describes how applying a resource would change the live state of the cluster.
+type ResourceDiff struct {
+	Kind      string
+	Namespace string
+	Name      string
+	// Created is true if the resource does not exist yet. Changes are empty in this case.
+	Created bool
+	Changes []FieldChange
+}
+
+// FieldChange describes the change of a single field.
+type FieldChange struct {
+	// Path addresses the field, f. e. `spec.template.spec.containers[0].image` or `metadata.labels["app.kubernetes.io/name"]`.
+	Path string
+	// Live is the current value of the field or nil if the field would be added.
+	Live any
+	// Desired is the value after applying the resource or nil if the field would be removed.
+	Desired any
+}
+
+// String formats the diff similar to `kubectl diff`.
+func (d ResourceDiff) String() string {
+	name := d.Name
+	if d.Namespace != "" {
+		name = d.Namespace + "/" + d.Name
+	}
+	if d.Created {
+		return fmt.Sprintf("%s %s: created\n", d.Kind, name)
+	}
+
+	builder := &strings.Builder{}
+	_, _ = fmt.Fprintf(builder, "%s %s:\n", d.Kind, name)
+	for _, change := range d.Changes {
+		_, _ = fmt.Fprintf(builder, "  %s: %v -> %v\n", change.Path, formatDiffValue(change.Live), formatDiffValue(change.Desired))
+	}
+	return builder.String()
+}
+
+// DryRun applies the resources of a YAML manifest with a server-side dry-run and returns the objects as the API server
+// would persist them, including defaulted fields. Nothing is changed in the cluster. Resources that depend on other
+// resources of the manifest, like objects in a new namespace, cannot be dry-run.
+func (ya *YamlApplier) DryRun(ctx context.Context, yamlBytes []byte) ([]*unstructured.Unstructured, error) {
+	documents, err := splitYamlDocuments("", yamlBytes)
+	if err != nil {
+		return nil, err
+	}
+	sortByApplyOrder(documents)
+
+	var objects []*unstructured.Unstructured
+	for _, document := range documents {
+		object, err := ya.dryRunDocument(ctx, document)
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, object)
+	}
+	return objects, nil
+}
+
+// Diff compares the resources of a YAML manifest with their live state. The desired state is computed by a server-side
+// dry-run, so fields defaulted by the API server do not show up as differences. Unchanged resources are omitted.
+func (ya *YamlApplier) Diff(ctx context.Context, yamlBytes []byte) ([]ResourceDiff, error) {
+	documents, err := splitYamlDocuments("", yamlBytes)
+	if err != nil {
+		return nil, err
+	}
+	sortByApplyOrder(documents)
+
+	var diffs []ResourceDiff
+	for _, document := range documents {
+		resource, namespace, err := ya.resourceFor(document)
+		if err != nil {
+			return nil, err
+		}
+		diff := ResourceDiff{Kind: document.object.GetKind(), Namespace: namespace, Name: document.object.GetName()}
+
+		live, err := resource.Get(ctx, document.object.GetName(), metav1.GetOptions{})
+		if k8sErrors.IsNotFound(err) {
+			diff.Created = true
+			diffs = append(diffs, diff)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get live state of %s: %w", document, err)
+		}
+
+		desired, err := ya.dryRunDocument(ctx, document)
+		if err != nil {
+			return nil, err
+		}
+
+		diff.Changes = diffFields(withoutIgnoredFields(live).Object, withoutIgnoredFields(desired).Object)
+		if len(diff.Changes) > 0 {
+			diffs = append(diffs, diff)
+		}
+	}
+	return diffs, nil
+}
+
+func (ya *YamlApplier) dryRunDocument(ctx context.Context, document yamlDocument) (*unstructured.Unstructured, error) {
+	resource, namespace, err := ya.resourceFor(document)
+	if err != nil {
+		return nil, err
+	}
+
+	object := document.object.DeepCopy()
+	object.SetNamespace(namespace)
+	patch, err := json.Marshal(object)
+	if err != nil {
+		return nil, fmt.Errorf("could not serialize %s: %w", document, err)
+	}
+
+	result, err := resource.Patch(ctx, object.GetName(), types.ApplyPatchType, patch, metav1.PatchOptions{
+		FieldManager: ya.fieldManager,
+		DryRun:       []string{metav1.DryRunAll},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to dry-run %s: %w", document, err)
+	}
+	return result, nil
+}
+
+func withoutIgnoredFields(object *unstructured.Unstructured) *unstructured.Unstructured {
+	stripped := object.DeepCopy()
+	for _, field := range ignoredDiffFields {
+		unstructured.RemoveNestedField(stripped.Object, field...)
+	}
+	return stripped
+}
+
+// diffFields returns the changes between two decoded JSON values. Maps are compared key by key, lists index by index.
+func diffFields(live, desired map[string]any) []FieldChange {
+	var changes []FieldChange
+	collectFieldChanges("", live, desired, &changes)
+	return changes
+}
+
+func collectFieldChanges(path string, live, desired any, changes *[]FieldChange) {
+	liveMap, liveIsMap := live.(map[string]any)
+	desiredMap, desiredIsMap := desired.(map[string]any)
+	if liveIsMap && desiredIsMap {
+		keys := map[string]bool{}
+		for key := range liveMap {
+			keys[key] = true
+		}
+		for key := range desiredMap {
+			keys[key] = true
+		}
+		sortedKeys := make([]string, 0, len(keys))
+		for key := range keys {
+			sortedKeys = append(sortedKeys, key)
+		}
+		sort.Strings(sortedKeys)
+
+		for _, key := range sortedKeys {
+			collectFieldChanges(fieldPath(path, key), liveMap[key], desiredMap[key], changes)
+		}
+		return
+	}
+
+	liveList, liveIsList := live.([]any)
+	desiredList, desiredIsList := desired.([]any)
+	if liveIsList && desiredIsList {
+		for i := 0; i < len(liveList) || i < len(desiredList); i++ {
+			var liveItem, desiredItem any
+			if i < len(liveList) {
+				liveItem = liveList[i]
+			}
+			if i < len(desiredList) {
+				desiredItem = desiredList[i]
+			}
+			collectFieldChanges(fmt.Sprintf("%s[%d]", path, i), liveItem, desiredItem, changes)
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(live, desired) {
+		*changes = append(*changes, FieldChange{Path: path, Live: live, Desired: desired})
+	}
+}
+
+func fieldPath(parent, key string) string {
+	if strings.ContainsAny(key, "./[]") {
+		return fmt.Sprintf("%s[%q]", parent, key)
+	}
+	if parent == "" {
+		return key
+	}
+	return parent + "." + key
+}
+
+func formatDiffValue(value any) string {
+	if value == nil {
+		return "<none>"
+	}
+	if _, isString := value.(string); isString {
+		return fmt.Sprintf("%q", value)
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(encoded)
+}
@@ -0,0 +1,103 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	dynamicFake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// reactToDryRun answers server-side dry-runs with the patched object plus a defaulted field.
+func reactToDryRun(t *testing.T, client *dynamicFake.FakeDynamicClient) {
+	client.PrependReactor("patch", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		patchAction := action.(k8stesting.PatchActionImpl)
+		assert.Equal(t, types.ApplyPatchType, patchAction.GetPatchType())
+
+		object := &unstructured.Unstructured{}
+		require.NoError(t, object.UnmarshalJSON(patchAction.GetPatch()))
+		require.NoError(t, unstructured.SetNestedField(object.Object, "Always", "spec", "pullPolicy"))
+		object.SetResourceVersion("42")
+		return true, object, nil
+	})
+}
+
+func TestYamlApplier_DryRun(t *testing.T) {
+	// given
+	client := newTestDynamicClient()
+	reactToDryRun(t, client)
+	sut := &YamlApplier{fieldManager: "test", dynamicClient: client, mapper: newTestMapper(), defaultNamespace: "team"}
+
+	// when
+	objects, err := sut.DryRun(context.Background(), []byte("apiVersion: v1\nkind: Pod\nmetadata:\n  name: echo\n"))
+
+	// then
+	require.NoError(t, err)
+	require.Len(t, objects, 1)
+	assert.Equal(t, "team", objects[0].GetNamespace())
+	pullPolicy, _, _ := unstructured.NestedString(objects[0].Object, "spec", "pullPolicy")
+	assert.Equal(t, "Always", pullPolicy)
+}
+
+func TestYamlApplier_Diff(t *testing.T) {
+	// given
+	live := parseTestObject(t, `{apiVersion: v1, kind: Pod, metadata: {name: echo, namespace: team, resourceVersion: "1", labels: {app.kubernetes.io/name: echo}}, spec: {image: "alpine:3.18", pullPolicy: Always, args: [a, b]}}`)
+	client := newTestDynamicClient(live)
+	reactToDryRun(t, client)
+	sut := &YamlApplier{fieldManager: "test", dynamicClient: client, mapper: newTestMapper(), defaultNamespace: "team"}
+	manifest := `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: echo
+  labels:
+    app.kubernetes.io/name: echo
+spec:
+  image: alpine:3.19
+  args: [a]
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: new
+`
+
+	// when
+	diffs, err := sut.Diff(context.Background(), []byte(manifest))
+
+	// then
+	require.NoError(t, err)
+	expected := []ResourceDiff{
+		{Kind: "Pod", Namespace: "team", Name: "echo", Changes: []FieldChange{
+			{Path: "spec.args[1]", Live: "b", Desired: nil},
+			{Path: "spec.image", Live: "alpine:3.18", Desired: "alpine:3.19"},
+		}},
+		{Kind: "ConfigMap", Namespace: "team", Name: "new", Created: true},
+	}
+	assert.Equal(t, expected, diffs)
+	assert.Equal(t, "Pod team/echo:\n  spec.args[1]: \"b\" -> <none>\n  spec.image: \"alpine:3.18\" -> \"alpine:3.19\"\n", diffs[0].String())
+	assert.Equal(t, "ConfigMap team/new: created\n", diffs[1].String())
+}
+
+func Test_diffFields(t *testing.T) {
+	// given
+	var live, desired map[string]any
+	require.NoError(t, json.Unmarshal([]byte(`{"metadata": {"labels": {"app.kubernetes.io/name": "a", "tier": "web"}}, "spec": {"replicas": 1}}`), &live))
+	require.NoError(t, json.Unmarshal([]byte(`{"metadata": {"labels": {"app.kubernetes.io/name": "b"}}, "spec": {"replicas": 1, "paused": true}}`), &desired))
+
+	// when
+	changes := diffFields(live, desired)
+
+	// then
+	assert.Equal(t, []FieldChange{
+		{Path: `metadata.labels["app.kubernetes.io/name"]`, Live: "a", Desired: "b"},
+		{Path: "metadata.labels.tier", Live: "web", Desired: nil},
+		{Path: "spec.paused", Live: nil, Desired: true},
+	}, changes)
+}
@@ -0,0 +1,179 @@
+package cluster
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+)
+
+// yamlDocument is a single resource of a multi-document YAML manifest.
+type yamlDocument struct {
+	// source names the file that contains the manifest. It is empty for manifests that were not read from a file.
+	source string
+	// index is the zero-based position of the document within the manifest.
+	index   int
+	content []byte
+	object  *unstructured.Unstructured
+}
+
+// String describes the document for error messages, f. e. `document 2 (Deployment nginx) of deploy/app.yaml`.
+func (d yamlDocument) String() string {
+	description := fmt.Sprintf("document %d (%s %s)", d.index+1, d.object.GetKind(), d.object.GetName())
+	if d.source != "" {
+		description += " of " + d.source
+	}
+	return description
+}
+
+// splitYamlDocuments splits a manifest at its `---` separators. Documents without content are skipped.
+func splitYamlDocuments(source string, manifest []byte) ([]yamlDocument, error) {
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(manifest)))
+
+	var documents []yamlDocument
+	for index := 0; ; index++ {
+		content, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return documents, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not read document %d%s: %w", index+1, sourceSuffix(source), err)
+		}
+
+		object := &unstructured.Unstructured{}
+		err = yaml.Unmarshal(content, &object.Object)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse document %d%s: %w", index+1, sourceSuffix(source), err)
+		}
+		if len(object.Object) == 0 {
+			continue
+		}
+
+		documents = append(documents, yamlDocument{source: source, index: index, content: content, object: object})
+	}
+}
+
+func sourceSuffix(source string) string {
+	if source == "" {
+		return ""
+	}
+	return " of " + source
+}
+
+// sortByApplyOrder orders the documents so that Namespaces and CustomResourceDefinitions are applied before the
+// resources that may depend on them. Otherwise, the original order is kept.
+func sortByApplyOrder(documents []yamlDocument) {
+	sort.SliceStable(documents, func(i, j int) bool {
+		return applyPriority(documents[i]) < applyPriority(documents[j])
+	})
+}
+
+func applyPriority(document yamlDocument) int {
+	switch document.object.GroupVersionKind().GroupKind().String() {
+	case "Namespace":
+		return 0
+	case crdGroupKind:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// findYamlFiles returns the sorted files that match one of the patterns. Without patterns, all YAML files are returned.
+func findYamlFiles(fsys fs.FS, patterns []string) ([]string, error) {
+	if len(patterns) == 0 {
+		var files []string
+		err := fs.WalkDir(fsys, ".", func(file string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !entry.IsDir() && isYamlFile(file) {
+				files = append(files, file)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("could not search for manifests: %w", err)
+		}
+		if len(files) == 0 {
+			return nil, errors.New("no YAML files found")
+		}
+		return files, nil
+	}
+
+	unique := map[string]bool{}
+	for _, pattern := range patterns {
+		matches, err := fs.Glob(fsys, pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid manifest pattern %q: %w", pattern, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no files match manifest pattern %q", pattern)
+		}
+		for _, match := range matches {
+			unique[match] = true
+		}
+	}
+
+	files := make([]string, 0, len(unique))
+	for file := range unique {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+func isYamlFile(file string) bool {
+	extension := path.Ext(file)
+	return extension == ".yaml" || extension == ".yml"
+}
+
+// readYamlPath reads the documents of a YAML file or of all YAML files in a directory.
+func readYamlPath(path string) ([]yamlDocument, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read manifests: %w", err)
+	}
+	if info.IsDir() {
+		return readYamlFS(os.DirFS(path), nil)
+	}
+
+	// read single files directly because their names may contain glob meta characters
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read manifest: %w", err)
+	}
+	return splitYamlDocuments(filepath.Base(path), content)
+}
+
+// readYamlFS reads the documents of the YAML files of a file system, see YamlApplier.ApplyFS.
+func readYamlFS(fsys fs.FS, patterns []string) ([]yamlDocument, error) {
+	files, err := findYamlFiles(fsys, patterns)
+	if err != nil {
+		return nil, err
+	}
+
+	var documents []yamlDocument
+	for _, file := range files {
+		content, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, fmt.Errorf("could not read manifest: %w", err)
+		}
+		fileDocuments, err := splitYamlDocuments(file, content)
+		if err != nil {
+			return nil, err
+		}
+		documents = append(documents, fileDocuments...)
+	}
+	return documents, nil
+}
@@ -0,0 +1,68 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path"
+
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+)
+
+// ApplyKustomize renders the kustomization in the given directory of a file system and applies the resulting manifest
+// like ApplyWithFile. The kustomization may refer to bases and resources anywhere in the file system, f. e.
+// `../base`. Remote bases are not supported.
+func (ya *YamlApplier) ApplyKustomize(ctx context.Context, fsys fs.FS, dir string) error {
+	manifest, err := renderKustomization(fsys, dir)
+	if err != nil {
+		return err
+	}
+
+	documents, err := splitYamlDocuments("kustomization "+dir, manifest)
+	if err != nil {
+		return err
+	}
+	return ya.applyDocuments(ctx, documents)
+}
+
+func renderKustomization(fsys fs.FS, dir string) ([]byte, error) {
+	kustomizeFs, err := copyToKustomizeFs(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	resources, err := krusty.MakeKustomizer(krusty.MakeDefaultOptions()).Run(kustomizeFs, path.Join("/", dir))
+	if err != nil {
+		return nil, fmt.Errorf("could not render kustomization %s: %w", dir, err)
+	}
+	manifest, err := resources.AsYaml()
+	if err != nil {
+		return nil, fmt.Errorf("could not serialize kustomization %s: %w", dir, err)
+	}
+	return manifest, nil
+}
+
+// copyToKustomizeFs copies a file system into memory because kustomize cannot read from fs.FS directly.
+func copyToKustomizeFs(fsys fs.FS) (filesys.FileSystem, error) {
+	kustomizeFs := filesys.MakeFsInMemory()
+	err := fs.WalkDir(fsys, ".", func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		target := path.Join("/", file)
+		if entry.IsDir() {
+			return kustomizeFs.MkdirAll(target)
+		}
+
+		content, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return err
+		}
+		return kustomizeFs.WriteFile(target, content)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not read kustomization files: %w", err)
+	}
+	return kustomizeFs, nil
+}
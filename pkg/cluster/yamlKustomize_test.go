@@ -0,0 +1,60 @@
+package cluster
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestYamlApplier_ApplyKustomize(t *testing.T) {
+	overlays := fstest.MapFS{
+		"deploy/base/kustomization.yaml": {Data: []byte("resources:\n  - config.yaml\n")},
+		"deploy/base/config.yaml":        {Data: []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: config\n")},
+		"deploy/overlays/dev/kustomization.yaml": {Data: []byte(`
+resources:
+  - ../../base
+  - namespace.yaml
+namePrefix: dev-
+`)},
+		"deploy/overlays/dev/namespace.yaml": {Data: []byte("apiVersion: v1\nkind: Namespace\nmetadata:\n  name: web\n")},
+	}
+
+	t.Run("should apply the rendered overlay", func(t *testing.T) {
+		// given
+		applier := &recordingApplier{}
+		sut := &YamlApplier{applier: applier, defaultNamespace: "default"}
+
+		// when
+		err := sut.ApplyKustomize(context.Background(), overlays, "deploy/overlays/dev")
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, []appliedDocument{
+			{kind: "Namespace", name: "web", namespace: "default"},
+			{kind: "ConfigMap", name: "dev-config", namespace: "default"},
+		}, applier.applied)
+	})
+	t.Run("should fail for missing kustomization", func(t *testing.T) {
+		// given
+		sut := &YamlApplier{applier: &recordingApplier{}, defaultNamespace: "default"}
+
+		// when
+		err := sut.ApplyKustomize(context.Background(), overlays, "deploy/overlays/prod")
+
+		// then
+		assert.ErrorContains(t, err, "could not render kustomization deploy/overlays/prod")
+	})
+	t.Run("should name the kustomization of the failed document", func(t *testing.T) {
+		// given
+		sut := &YamlApplier{applier: &recordingApplier{failName: "dev-config"}, defaultNamespace: "default"}
+
+		// when
+		err := sut.ApplyKustomize(context.Background(), overlays, "deploy/overlays/dev")
+
+		// then
+		assert.EqualError(t, err, "failed to apply document 1 (ConfigMap dev-config) of kustomization deploy/overlays/dev: assert error")
+	})
+}
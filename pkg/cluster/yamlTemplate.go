@@ -0,0 +1,62 @@
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"text/template"
+
+	"github.com/Masterminds/sprig/v3"
+)
+
+// TemplateValues are the values that templates applied with YamlApplier.ApplyTemplate can refer to, f. e.
+// `{{ .Namespace }}` or `{{ .RegistryImage "app:1.0" }}`.
+type TemplateValues struct {
+	// Namespace is the default namespace of the applier.
+	Namespace string
+	// ClusterName is the name of the cluster that the applier applies to.
+	ClusterName string
+	// Registry is the host and port of the cluster's image registry as seen from inside the cluster.
+	Registry string
+	// Values contains the test-specific data passed to ApplyTemplate.
+	Values any
+}
+
+// RegistryImage returns the reference of an image in the cluster's image registry.
+func (v TemplateValues) RegistryImage(image string) string {
+	if v.Registry == "" {
+		return image
+	}
+	return v.Registry + "/" + image
+}
+
+// ApplyTemplate renders a YAML manifest with text/template and applies it like ApplyWithFile. The template refers to
+// TemplateValues, the given data is available as `{{ .Values }}`. The sprig functions like `quote`, `upper` or
+// `randAlphaNum` can be used as well. Referring to missing map keys fails the rendering.
+func (ya *YamlApplier) ApplyTemplate(ctx context.Context, tmpl []byte, data any) error {
+	manifest, err := ya.renderTemplate(tmpl, data)
+	if err != nil {
+		return err
+	}
+	return ya.ApplyWithFile(ctx, manifest)
+}
+
+func (ya *YamlApplier) renderTemplate(tmpl []byte, data any) ([]byte, error) {
+	parsed, err := template.New("manifest").Funcs(sprig.TxtFuncMap()).Option("missingkey=error").Parse(string(tmpl))
+	if err != nil {
+		return nil, fmt.Errorf("could not parse manifest template: %w", err)
+	}
+
+	values := TemplateValues{
+		Namespace:   ya.defaultNamespace,
+		ClusterName: ya.clusterName,
+		Registry:    ya.registry,
+		Values:      data,
+	}
+	rendered := &bytes.Buffer{}
+	err = parsed.Execute(rendered, values)
+	if err != nil {
+		return nil, fmt.Errorf("could not render manifest template: %w", err)
+	}
+	return rendered.Bytes(), nil
+}
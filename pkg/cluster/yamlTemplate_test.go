@@ -0,0 +1,81 @@
+package cluster
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const templatedManifest = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ .Values.name }}
+  namespace: {{ .Namespace }}
+  labels:
+    cluster: {{ .ClusterName | quote }}
+spec:
+  template:
+    spec:
+      containers:
+        - name: app
+          image: {{ .RegistryImage "app:1.0" }}
+          command: [{{ "serve" | upper | quote }}]
+`
+
+func TestYamlApplier_renderTemplate(t *testing.T) {
+	t.Run("should render cluster values and data", func(t *testing.T) {
+		// given
+		sut := &YamlApplier{defaultNamespace: "test-ns", clusterName: "my-cluster", registry: "k3d-my-cluster-registry:5000"}
+
+		// when
+		actual, err := sut.renderTemplate([]byte(templatedManifest), map[string]any{"name": "my-app"})
+
+		// then
+		require.NoError(t, err)
+		assert.Contains(t, string(actual), "  name: my-app\n  namespace: test-ns\n")
+		assert.Contains(t, string(actual), `cluster: "my-cluster"`)
+		assert.Contains(t, string(actual), "image: k3d-my-cluster-registry:5000/app:1.0")
+		assert.Contains(t, string(actual), `command: ["SERVE"]`)
+	})
+	t.Run("should fail on missing values", func(t *testing.T) {
+		// given
+		sut := &YamlApplier{defaultNamespace: "test-ns"}
+
+		// when
+		_, err := sut.renderTemplate([]byte("name: {{ .Values.name }}"), map[string]any{})
+
+		// then
+		assert.ErrorContains(t, err, "could not render manifest template")
+	})
+	t.Run("should fail on invalid template", func(t *testing.T) {
+		// given
+		sut := &YamlApplier{defaultNamespace: "test-ns"}
+
+		// when
+		_, err := sut.renderTemplate([]byte("name: {{ .Values.name "), nil)
+
+		// then
+		assert.ErrorContains(t, err, "could not parse manifest template")
+	})
+}
+
+func TestYamlApplier_ApplyTemplate(t *testing.T) {
+	// given
+	applier := &recordingApplier{}
+	sut := &YamlApplier{applier: applier, defaultNamespace: "test-ns"}
+
+	// when
+	err := sut.ApplyTemplate(context.Background(), []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: {{ .Values }}\n"), "config")
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, []appliedDocument{{kind: "ConfigMap", name: "config", namespace: "test-ns"}}, applier.applied)
+}
+
+func TestTemplateValues_RegistryImage(t *testing.T) {
+	assert.Equal(t, "registry:5000/app", TemplateValues{Registry: "registry:5000"}.RegistryImage("app"))
+	assert.Equal(t, "app", TemplateValues{}.RegistryImage("app"))
+}
@@ -0,0 +1,206 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const resourceReadinessPollInterval = 500 * time.Millisecond
+
+// customKindsWithoutStatus contains custom kinds whose resources never report a status, so waiting for them is futile.
+var customKindsWithoutStatus = map[string]bool{
+	"HelmChart.helm.cattle.io":       true,
+	"HelmChartConfig.helm.cattle.io": true,
+}
+
+// errResourceFailed marks resources that will never become ready, like failed jobs.
+var errResourceFailed = errors.New("resource failed")
+
+// readinessCheck returns nil if the object is ready. Otherwise, the returned error describes why it is not ready yet.
+type readinessCheck func(object *unstructured.Unstructured) error
+
+// ApplyAndWait applies a YAML manifest like ApplyWithFile and waits until the applied resources are ready:
+//   - Deployments have rolled out all replicas,
+//   - Jobs are complete,
+//   - Pods are ready or succeeded,
+//   - CustomResourceDefinitions are established and
+//   - custom resources report the condition `Ready`, or were reconciled without reporting it, i. e. their
+//     `status.observedGeneration` reached `metadata.generation`.
+//
+// Other resources, including custom resources that never report a status like `helm.cattle.io/HelmChart`, are ready as
+// soon as they are applied. ApplyAndWait fails early if a Job or Pod failed.
+func (ya *YamlApplier) ApplyAndWait(ctx context.Context, yamlBytes []byte, timeout time.Duration) error {
+	documents, err := splitYamlDocuments("", yamlBytes)
+	if err != nil {
+		return err
+	}
+	err = ya.applyDocuments(ctx, documents)
+	if err != nil {
+		return err
+	}
+	return ya.waitForDocuments(ctx, documents, timeout)
+}
+
+func (ya *YamlApplier) waitForDocuments(ctx context.Context, documents []yamlDocument, timeout time.Duration) error {
+	var pending []yamlDocument
+	for _, document := range documents {
+		if readinessCheckFor(document) != nil {
+			pending = append(pending, document)
+		}
+	}
+	lastErrors := map[int]error{}
+
+	err := wait.PollUntilContextTimeout(ctx, resourceReadinessPollInterval, timeout, true, func(ctx context.Context) (bool, error) {
+		var notReady []yamlDocument
+		for _, document := range pending {
+			err := ya.checkReadiness(ctx, document)
+			if errors.Is(err, errResourceFailed) {
+				return false, fmt.Errorf("%s will not become ready: %w", document, err)
+			}
+			if err != nil {
+				lastErrors[document.index] = err
+				notReady = append(notReady, document)
+			}
+		}
+		pending = notReady
+		return len(pending) == 0, nil
+	})
+	if err == nil || !wait.Interrupted(err) {
+		return err
+	}
+
+	var reasons []string
+	for _, document := range pending {
+		reason := "not checked"
+		if lastErrors[document.index] != nil {
+			reason = lastErrors[document.index].Error()
+		}
+		reasons = append(reasons, fmt.Sprintf("%s (%s)", document, reason))
+	}
+	return fmt.Errorf("resources did not become ready within %s: %s: %w", timeout, strings.Join(reasons, ", "), err)
+}
+
+func (ya *YamlApplier) checkReadiness(ctx context.Context, document yamlDocument) error {
+	resource, _, err := ya.resourceFor(document)
+	if err != nil {
+		return err
+	}
+	object, err := resource.Get(ctx, document.object.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	return readinessCheckFor(document)(object)
+}
+
+// readinessCheckFor returns the readiness check for the kind of the document or nil if the kind has no readiness.
+func readinessCheckFor(document yamlDocument) readinessCheck {
+	gvk := document.object.GroupVersionKind()
+	switch gvk.GroupKind().String() {
+	case "Deployment.apps":
+		return deploymentReady
+	case "Job.batch":
+		return jobComplete
+	case "Pod":
+		return podReady
+	case crdGroupKind:
+		return conditionTrue("Established")
+	}
+
+	if isCustomGroup(gvk.Group) && !customKindsWithoutStatus[gvk.GroupKind().String()] {
+		return customResourceReady
+	}
+	return nil
+}
+
+// isCustomGroup returns true for API groups that are not part of Kubernetes itself.
+func isCustomGroup(group string) bool {
+	return strings.Contains(group, ".") && !strings.HasSuffix(group, ".k8s.io")
+}
+
+func deploymentReady(object *unstructured.Unstructured) error {
+	desired, found, _ := unstructured.NestedInt64(object.Object, "spec", "replicas")
+	if !found {
+		desired = 1
+	}
+	observedGeneration, _, _ := unstructured.NestedInt64(object.Object, "status", "observedGeneration")
+	updated, _, _ := unstructured.NestedInt64(object.Object, "status", "updatedReplicas")
+	available, _, _ := unstructured.NestedInt64(object.Object, "status", "availableReplicas")
+
+	if observedGeneration < object.GetGeneration() || updated < desired || available < desired {
+		return fmt.Errorf("deployment has %d/%d available replicas", available, desired)
+	}
+	return nil
+}
+
+func jobComplete(object *unstructured.Unstructured) error {
+	if status, message := findCondition(object, "Failed"); status == "True" {
+		return fmt.Errorf("job failed: %s: %w", message, errResourceFailed)
+	}
+	return conditionTrue("Complete")(object)
+}
+
+func podReady(object *unstructured.Unstructured) error {
+	phase, _, _ := unstructured.NestedString(object.Object, "status", "phase")
+	if phase == "Failed" {
+		return fmt.Errorf("pod failed: %w", errResourceFailed)
+	}
+	if phase == "Succeeded" {
+		// completed pods are not ready anymore
+		return nil
+	}
+	return conditionTrue("Ready")(object)
+}
+
+func conditionTrue(conditionType string) readinessCheck {
+	return func(object *unstructured.Unstructured) error {
+		status, message := findCondition(object, conditionType)
+		if status == "True" {
+			return nil
+		}
+		if status == "" {
+			return fmt.Errorf("condition %s is not reported yet", conditionType)
+		}
+		return fmt.Errorf("condition %s is %s: %s", conditionType, status, message)
+	}
+}
+
+// customResourceReady checks the condition Ready of custom resources. Operators that do not report the condition are
+// done once they observed the current generation of the resource.
+func customResourceReady(object *unstructured.Unstructured) error {
+	status, message := findCondition(object, "Ready")
+	if status == "True" {
+		return nil
+	}
+	if status != "" {
+		return fmt.Errorf("condition Ready is %s: %s", status, message)
+	}
+
+	observedGeneration, found, _ := unstructured.NestedInt64(object.Object, "status", "observedGeneration")
+	if found && observedGeneration >= object.GetGeneration() {
+		return nil
+	}
+	return fmt.Errorf("condition Ready is not reported yet")
+}
+
+// findCondition returns the status and message of a condition in `status.conditions`. The status is empty if the
+// condition does not exist.
+func findCondition(object *unstructured.Unstructured, conditionType string) (status, message string) {
+	conditions, _, _ := unstructured.NestedSlice(object.Object, "status", "conditions")
+	for _, condition := range conditions {
+		fields, ok := condition.(map[string]any)
+		if !ok || fields["type"] != conditionType {
+			continue
+		}
+		status, _ = fields["status"].(string)
+		message, _ = fields["message"].(string)
+		return status, message
+	}
+	return "", ""
+}
@@ -0,0 +1,150 @@
+package cluster
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stesting "k8s.io/client-go/testing"
+	"sigs.k8s.io/yaml"
+)
+
+func parseTestObject(t *testing.T, manifest string) *unstructured.Unstructured {
+	t.Helper()
+	json, err := yaml.YAMLToJSON([]byte(manifest))
+	require.NoError(t, err)
+	object := &unstructured.Unstructured{}
+	require.NoError(t, object.UnmarshalJSON(json))
+	return object
+}
+
+func Test_readinessCheckFor(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		wantErr  string
+	}{
+		{"ready deployment", `{apiVersion: apps/v1, kind: Deployment, spec: {replicas: 2}, status: {updatedReplicas: 2, availableReplicas: 2}}`, ""},
+		{"unready deployment", `{apiVersion: apps/v1, kind: Deployment, status: {updatedReplicas: 1}}`, "deployment has 0/1 available replicas"},
+		{"outdated deployment", `{apiVersion: apps/v1, kind: Deployment, metadata: {generation: 2}, status: {observedGeneration: 1, updatedReplicas: 1, availableReplicas: 1}}`, "deployment has 1/1 available replicas"},
+		{"complete job", `{apiVersion: batch/v1, kind: Job, status: {conditions: [{type: Complete, status: "True"}]}}`, ""},
+		{"running job", `{apiVersion: batch/v1, kind: Job}`, "condition Complete is not reported yet"},
+		{"failed job", `{apiVersion: batch/v1, kind: Job, status: {conditions: [{type: Failed, status: "True", message: BackoffLimitExceeded}]}}`, "job failed: BackoffLimitExceeded: resource failed"},
+		{"ready pod", `{apiVersion: v1, kind: Pod, status: {conditions: [{type: Ready, status: "True"}]}}`, ""},
+		{"unready pod", `{apiVersion: v1, kind: Pod, status: {conditions: [{type: Ready, status: "False", message: containers not ready}]}}`, "condition Ready is False: containers not ready"},
+		{"succeeded pod", `{apiVersion: v1, kind: Pod, status: {phase: Succeeded, conditions: [{type: Ready, status: "False", reason: PodCompleted}]}}`, ""},
+		{"failed pod", `{apiVersion: v1, kind: Pod, status: {phase: Failed}}`, "pod failed: resource failed"},
+		{"established CRD", `{apiVersion: apiextensions.k8s.io/v1, kind: CustomResourceDefinition, status: {conditions: [{type: Established, status: "True"}]}}`, ""},
+		{"ready custom resource", `{apiVersion: example.com/v1, kind: Widget, status: {conditions: [{type: Ready, status: "True"}]}}`, ""},
+		{"unready custom resource", `{apiVersion: example.com/v1, kind: Widget, status: {conditions: [{type: Ready, status: "False", message: reconciling}]}}`, "condition Ready is False: reconciling"},
+		{"fresh custom resource", `{apiVersion: example.com/v1, kind: Widget, metadata: {generation: 1}}`, "condition Ready is not reported yet"},
+		{"reconciled custom resource without ready condition", `{apiVersion: example.com/v1, kind: Widget, metadata: {generation: 2}, status: {observedGeneration: 2}}`, ""},
+		{"outdated custom resource without ready condition", `{apiVersion: example.com/v1, kind: Widget, metadata: {generation: 2}, status: {observedGeneration: 1}}`, "condition Ready is not reported yet"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			object := parseTestObject(t, tt.manifest)
+
+			err := readinessCheckFor(yamlDocument{object: object})(object)
+
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+
+	t.Run("kinds without readiness", func(t *testing.T) {
+		for _, manifest := range []string{`{apiVersion: v1, kind: ConfigMap}`, `{apiVersion: networking.k8s.io/v1, kind: Ingress}`, `{apiVersion: helm.cattle.io/v1, kind: HelmChart}`} {
+			assert.Nil(t, readinessCheckFor(yamlDocument{object: parseTestObject(t, manifest)}), manifest)
+		}
+	})
+}
+
+const waitManifest = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+---
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: migration
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+`
+
+func TestYamlApplier_ApplyAndWait(t *testing.T) {
+	readyDeployment := `{apiVersion: apps/v1, kind: Deployment, metadata: {name: nginx, namespace: default}, status: {updatedReplicas: 1, availableReplicas: 1}}`
+	completeJob := `{apiVersion: batch/v1, kind: Job, metadata: {name: migration, namespace: default}, status: {conditions: [{type: Complete, status: "True"}]}}`
+
+	t.Run("should apply and wait for readiness", func(t *testing.T) {
+		// given
+		applier := &recordingApplier{}
+		client := newTestDynamicClient(parseTestObject(t, readyDeployment), parseTestObject(t, completeJob))
+		sut := &YamlApplier{applier: applier, dynamicClient: client, mapper: newTestMapper(), defaultNamespace: "default"}
+
+		// when
+		err := sut.ApplyAndWait(context.Background(), []byte(waitManifest), 5*time.Second)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, []string{"nginx", "migration", "config"}, appliedNames(applier))
+	})
+	t.Run("should name resources that did not become ready", func(t *testing.T) {
+		// given
+		client := newTestDynamicClient(parseTestObject(t, readyDeployment))
+		sut := &YamlApplier{applier: &recordingApplier{}, dynamicClient: client, mapper: newTestMapper(), defaultNamespace: "default"}
+
+		// when
+		err := sut.ApplyAndWait(context.Background(), []byte(waitManifest), time.Second)
+
+		// then
+		require.Error(t, err)
+		assert.ErrorContains(t, err, `resources did not become ready within 1s: document 2 (Job migration) (jobs.batch "migration" not found)`)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+	t.Run("should wait for the status of custom resources", func(t *testing.T) {
+		// given
+		freshWidget := parseTestObject(t, `{apiVersion: example.com/v1, kind: Widget, metadata: {name: gear, namespace: default, generation: 1}}`)
+		readyWidget := parseTestObject(t, `{apiVersion: example.com/v1, kind: Widget, metadata: {name: gear, namespace: default, generation: 1}, status: {conditions: [{type: Ready, status: "True"}]}}`)
+		client := newTestDynamicClient(freshWidget)
+		gets := 0
+		client.PrependReactor("get", "widgets", func(k8stesting.Action) (bool, runtime.Object, error) {
+			gets++
+			if gets < 3 {
+				return true, freshWidget, nil
+			}
+			return true, readyWidget, nil
+		})
+		sut := &YamlApplier{applier: &recordingApplier{}, dynamicClient: client, mapper: newTestMapper(), defaultNamespace: "default"}
+
+		// when
+		err := sut.ApplyAndWait(context.Background(), []byte("apiVersion: example.com/v1\nkind: Widget\nmetadata:\n  name: gear\n"), 5*time.Second)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, 3, gets)
+	})
+	t.Run("should fail early for failed jobs", func(t *testing.T) {
+		// given
+		failedJob := `{apiVersion: batch/v1, kind: Job, metadata: {name: migration, namespace: default}, status: {conditions: [{type: Failed, status: "True", message: BackoffLimitExceeded}]}}`
+		client := newTestDynamicClient(parseTestObject(t, readyDeployment), parseTestObject(t, failedJob))
+		sut := &YamlApplier{applier: &recordingApplier{}, dynamicClient: client, mapper: newTestMapper(), defaultNamespace: "default"}
+
+		// when
+		err := sut.ApplyAndWait(context.Background(), []byte(waitManifest), time.Minute)
+
+		// then
+		assert.EqualError(t, err, "document 2 (Job migration) will not become ready: job failed: BackoffLimitExceeded: resource failed")
+	})
+}
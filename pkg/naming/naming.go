@@ -1,31 +1,127 @@
 package naming
 
 import (
+	"crypto/rand"
 	"crypto/sha256"
+	"encoding/binary"
 	"encoding/hex"
 	"fmt"
-	"time"
+	"strings"
+	"sync"
 
 	"k8s.io/apimachinery/pkg/util/validation"
 )
 
-func MustGenerateK8sName(prefix string) string {
+// hashLength is the number of hex characters that are appended to a name prefix.
+const hashLength = 8
+
+var defaultGenerator = NewGenerator()
+
+// Generator creates names that are valid DNS-1123 labels and thus can be used for most Kubernetes objects.
+type Generator struct {
+	// seed makes the generated names reproducible. Without a seed, names are based on crypto randomness.
+	seed []byte
+
+	mu      sync.Mutex
+	counter uint64
+}
+
+// NewGenerator returns a generator which creates names from crypto randomness. Names of different generators do not
+// collide with a high probability.
+func NewGenerator() *Generator {
+	return &Generator{}
+}
+
+// NewSeededGenerator returns a generator which creates the same sequence of names for the same seed, f. e. a test name.
+// This is useful to reproduce the names of a previous test run. Seeded names are only unique within one generator: other
+// generators with the same seed, also in other processes, create the very same names. Use a seed that is unique among
+// concurrently running processes, or NewGenerator, for objects that are shared between processes.
+func NewSeededGenerator(seed string) *Generator {
+	return &Generator{seed: []byte(seed)}
+}
+
+// GenerateK8sName appends a short hash to the given prefix. A prefix that is too long to form a DNS-1123 label together
+// with the hash is truncated. An error is returned if the prefix contains characters that are not allowed in a
+// DNS-1123 label.
+func (g *Generator) GenerateK8sName(prefix string) (string, error) {
+	return g.GenerateK8sNameWithMaxLength(prefix, validation.DNS1123LabelMaxLength)
+}
+
+// GenerateK8sNameWithMaxLength works like GenerateK8sName but truncates the prefix so that the name has at most
+// maxLength characters, f. e. to respect the length limit of k3d cluster names. The hash is kept in any case.
+func (g *Generator) GenerateK8sNameWithMaxLength(prefix string, maxLength int) (string, error) {
+	if maxLength < hashLength || maxLength > validation.DNS1123LabelMaxLength {
+		return "", fmt.Errorf("maxLength must be between %d and %d, but is %d", hashLength, validation.DNS1123LabelMaxLength, maxLength)
+	}
+
+	maxPrefixLength := maxLength - hashLength - 1
+	if len(prefix) > maxPrefixLength {
+		prefix = strings.TrimRight(prefix[:max(maxPrefixLength, 0)], "-")
+	}
 	if prefix != "" {
 		rfcCheckErrs := validation.IsDNS1123Label(prefix)
 		if rfcCheckErrs != nil {
-			panic(fmt.Sprintf("prefix is not an RFC 1123 compatible identifier: %v", rfcCheckErrs))
+			return "", fmt.Errorf("prefix is not an RFC 1123 compatible identifier: %v", rfcCheckErrs)
 		}
 	}
 
-	now := time.Now().String()
-	h := sha256.New()
-	h.Write([]byte(now))
-	hash := hex.EncodeToString(h.Sum(nil))
+	hash, err := g.nextHash()
+	if err != nil {
+		return "", err
+	}
 
 	delimiter := ""
 	if prefix != "" {
 		delimiter = "-"
 	}
 
-	return prefix + delimiter + hash[0:8]
+	return prefix + delimiter + hash, nil
+}
+
+// MustGenerateK8sName works like GenerateK8sName but panics on errors.
+func (g *Generator) MustGenerateK8sName(prefix string) string {
+	name, err := g.GenerateK8sName(prefix)
+	if err != nil {
+		panic(err.Error())
+	}
+	return name
+}
+
+func (g *Generator) nextHash() (string, error) {
+	h := sha256.New()
+	if g.seed == nil {
+		randomBytes := make([]byte, 16)
+		_, err := rand.Read(randomBytes)
+		if err != nil {
+			return "", fmt.Errorf("could not read random bytes: %w", err)
+		}
+		h.Write(randomBytes)
+	} else {
+		g.mu.Lock()
+		counter := g.counter
+		g.counter++
+		g.mu.Unlock()
+
+		h.Write(g.seed)
+		h.Write(binary.BigEndian.AppendUint64(nil, counter))
+	}
+
+	return hex.EncodeToString(h.Sum(nil))[:hashLength], nil
+}
+
+// GenerateK8sName appends a random short hash to the given prefix. See Generator.GenerateK8sName.
+func GenerateK8sName(prefix string) (string, error) {
+	return defaultGenerator.GenerateK8sName(prefix)
+}
+
+// GenerateK8sNameWithMaxLength appends a random short hash to the given prefix. See
+// Generator.GenerateK8sNameWithMaxLength.
+func GenerateK8sNameWithMaxLength(prefix string, maxLength int) (string, error) {
+	return defaultGenerator.GenerateK8sNameWithMaxLength(prefix, maxLength)
+}
+
+// MustGenerateK8sName appends a random short hash to the given prefix and panics if the prefix is not a valid DNS-1123
+// label. See Generator.GenerateK8sName.
+func MustGenerateK8sName(prefix string) string {
+	return defaultGenerator.MustGenerateK8sName(prefix)
 }
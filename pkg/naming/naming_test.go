@@ -2,7 +2,10 @@ package naming
 
 import (
 	"regexp"
+	"strings"
 	"testing"
+
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
 func TestMustGenerateK8sName(t *testing.T) {
@@ -33,3 +36,90 @@ func TestMustGenerateK8sName(t *testing.T) {
 		_ = MustGenerateK8sName("ÜŞ$")
 	})
 }
+
+func TestMustGenerateK8sName_Unique(t *testing.T) {
+	const count = 100
+	names := make(map[string]bool, count)
+	for i := 0; i < count; i++ {
+		names[MustGenerateK8sName("cluster")] = true
+	}
+
+	if len(names) != count {
+		t.Errorf("MustGenerateK8sName() generated %d duplicate names", count-len(names))
+	}
+}
+
+func TestGenerateK8sName(t *testing.T) {
+	t.Run("should truncate long prefix", func(t *testing.T) {
+		prefix := strings.Repeat("a", 53) + "-" + strings.Repeat("b", 20)
+
+		got, err := GenerateK8sName(prefix)
+
+		if err != nil {
+			t.Fatalf("GenerateK8sName() returned unexpected error: %v", err)
+		}
+		r := regexp.MustCompile("^a{53}-[a-f0-9]{8}$")
+		if !r.MatchString(got) {
+			t.Errorf("GenerateK8sName() = %v, must match regexp: %v", got, r)
+		}
+		if errs := validation.IsDNS1123Label(got); errs != nil {
+			t.Errorf("GenerateK8sName() = %v is no DNS-1123 label: %v", got, errs)
+		}
+	})
+	t.Run("should truncate prefix to max length", func(t *testing.T) {
+		got, err := GenerateK8sNameWithMaxLength("testclusters-go-"+strings.Repeat("a", 20), 32)
+
+		if err != nil {
+			t.Fatalf("GenerateK8sNameWithMaxLength() returned unexpected error: %v", err)
+		}
+		r := regexp.MustCompile("^testclusters-go-a{7}-[a-f0-9]{8}$")
+		if !r.MatchString(got) {
+			t.Errorf("GenerateK8sNameWithMaxLength() = %v, must match regexp: %v", got, r)
+		}
+	})
+	t.Run("should only keep hash for max length of hash", func(t *testing.T) {
+		got, err := GenerateK8sNameWithMaxLength("prefix", 8)
+
+		if err != nil {
+			t.Fatalf("GenerateK8sNameWithMaxLength() returned unexpected error: %v", err)
+		}
+		r := regexp.MustCompile("^[a-f0-9]{8}$")
+		if !r.MatchString(got) {
+			t.Errorf("GenerateK8sNameWithMaxLength() = %v, must match regexp: %v", got, r)
+		}
+	})
+	t.Run("should return error on invalid max length", func(t *testing.T) {
+		_, err := GenerateK8sNameWithMaxLength("prefix", 7)
+
+		if err == nil || !strings.Contains(err.Error(), "maxLength must be between 8 and 63, but is 7") {
+			t.Errorf("GenerateK8sNameWithMaxLength() error = %v, want maxLength error", err)
+		}
+	})
+	t.Run("should return error on invalid prefix", func(t *testing.T) {
+		_, err := GenerateK8sName("Test_Something")
+
+		if err == nil || !strings.Contains(err.Error(), "prefix is not an RFC 1123 compatible identifier") {
+			t.Errorf("GenerateK8sName() error = %v, want RFC 1123 error", err)
+		}
+	})
+}
+
+func TestNewSeededGenerator(t *testing.T) {
+	first := NewSeededGenerator("TestSomething")
+	second := NewSeededGenerator("TestSomething")
+	other := NewSeededGenerator("TestOther")
+
+	firstNames := []string{first.MustGenerateK8sName("ns"), first.MustGenerateK8sName("ns")}
+	secondNames := []string{second.MustGenerateK8sName("ns"), second.MustGenerateK8sName("ns")}
+	otherName := other.MustGenerateK8sName("ns")
+
+	if firstNames[0] == firstNames[1] {
+		t.Errorf("seeded generator repeated name %v", firstNames[0])
+	}
+	if firstNames[0] != secondNames[0] || firstNames[1] != secondNames[1] {
+		t.Errorf("seeded generators differ: %v vs. %v", firstNames, secondNames)
+	}
+	if otherName == firstNames[0] {
+		t.Errorf("generators with different seeds created the same name %v", otherName)
+	}
+}
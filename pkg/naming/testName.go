@@ -0,0 +1,70 @@
+package naming
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// TestNamer is implemented by *testing.T, *testing.B and testing.TB.
+type TestNamer interface {
+	Name() string
+}
+
+var invalidLabelChars = regexp.MustCompile(`[^a-z0-9-]+`)
+var repeatedHyphens = regexp.MustCompile(`-{2,}`)
+
+// FromTestName derives a DNS-1123 label from the name of the given test. Subtest delimiters, uppercase letters and
+// other invalid characters are replaced, and a short hash of the original test name is appended so that subtests with
+// similar names do not collide. The result is stable across test runs and can be used for namespaces, field managers
+// or as a name prefix.
+func FromTestName(t TestNamer) string {
+	return FromTestNameWithMaxLength(t, validation.DNS1123LabelMaxLength)
+}
+
+// FromTestNameWithMaxLength works like FromTestName but truncates the result to maxLength characters, f. e. to respect
+// the length limit of k3d cluster names. The hash is kept in any case.
+func FromTestNameWithMaxLength(t TestNamer, maxLength int) string {
+	if maxLength < hashLength {
+		panic(fmt.Sprintf("maxLength must be at least %d, but is %d", hashLength, maxLength))
+	}
+	return withTestNameHash(sanitizeLabel(t.Name()), t.Name(), maxLength)
+}
+
+// SubdomainFromTestName derives a DNS-1123 subdomain from the name of the given test. Each subtest level becomes a
+// subdomain part. Like FromTestName, a short hash of the original test name is appended.
+func SubdomainFromTestName(t TestNamer) string {
+	var parts []string
+	for _, part := range strings.Split(t.Name(), "/") {
+		if sanitized := sanitizeLabel(part); sanitized != "" {
+			parts = append(parts, sanitized)
+		}
+	}
+	return withTestNameHash(strings.Join(parts, "."), t.Name(), validation.DNS1123SubdomainMaxLength)
+}
+
+func sanitizeLabel(name string) string {
+	label := invalidLabelChars.ReplaceAllString(strings.ToLower(name), "-")
+	label = repeatedHyphens.ReplaceAllString(label, "-")
+	return strings.Trim(label, "-")
+}
+
+func withTestNameHash(sanitized, testName string, maxLength int) string {
+	h := sha256.Sum256([]byte(testName))
+	hash := hex.EncodeToString(h[:])[:hashLength]
+
+	maxSanitizedLength := maxLength - hashLength - 1
+	if len(sanitized) > maxSanitizedLength {
+		sanitized = sanitized[:max(maxSanitizedLength, 0)]
+	}
+	sanitized = strings.TrimRight(sanitized, "-.")
+	if sanitized == "" {
+		return hash
+	}
+
+	return sanitized + "-" + hash
+}
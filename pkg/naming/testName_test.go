@@ -0,0 +1,73 @@
+package naming
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+type fakeTest string
+
+func (f fakeTest) Name() string {
+	return string(f)
+}
+
+func TestFromTestName(t *testing.T) {
+	tests := []struct {
+		name     string
+		testName string
+		want     string
+	}{
+		{"subtest delimiters become hyphens", "TestSomething/should_work", "^testsomething-should-work-[a-f0-9]{8}$"},
+		{"special characters are collapsed", "Test__Über/#01", "^test-ber-01-[a-f0-9]{8}$"},
+		{"only invalid characters lead to hash", "_/_", "^[a-f0-9]{8}$"},
+		{"long names are truncated", "Test" + strings.Repeat("x", 100), "^testx{50}-[a-f0-9]{8}$"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FromTestName(fakeTest(tt.testName))
+
+			if !regexp.MustCompile(tt.want).MatchString(got) {
+				t.Errorf("FromTestName() = %v, must match regexp: %v", got, tt.want)
+			}
+			if errs := validation.IsDNS1123Label(got); errs != nil {
+				t.Errorf("FromTestName() = %v is no DNS-1123 label: %v", got, errs)
+			}
+		})
+	}
+
+	t.Run("should be stable", func(t *testing.T) {
+		if FromTestName(t) != FromTestName(t) {
+			t.Errorf("FromTestName() is not stable for %v", t.Name())
+		}
+	})
+	t.Run("should distinguish similar names", func(t *testing.T) {
+		first := FromTestName(fakeTest("TestSomething/a_b"))
+		second := FromTestName(fakeTest("TestSomething/a-b"))
+
+		if first == second {
+			t.Errorf("FromTestName() returned %v for different test names", first)
+		}
+	})
+}
+
+func TestFromTestNameWithMaxLength(t *testing.T) {
+	got := FromTestNameWithMaxLength(fakeTest("TestClusterCreation/with_agents"), 32)
+
+	if !regexp.MustCompile("^testclustercreation-wit-[a-f0-9]{8}$").MatchString(got) {
+		t.Errorf("FromTestNameWithMaxLength() = %v", got)
+	}
+}
+
+func TestSubdomainFromTestName(t *testing.T) {
+	got := SubdomainFromTestName(fakeTest("TestSomething/should_work/#01"))
+
+	if !regexp.MustCompile(`^testsomething\.should-work\.01-[a-f0-9]{8}$`).MatchString(got) {
+		t.Errorf("SubdomainFromTestName() = %v", got)
+	}
+	if errs := validation.IsDNS1123Subdomain(got); errs != nil {
+		t.Errorf("SubdomainFromTestName() = %v is no DNS-1123 subdomain: %v", got, errs)
+	}
+}